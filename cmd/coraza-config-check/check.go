@@ -0,0 +1,334 @@
+// Copyright © 2026 United Security Providers AG, Switzerland
+// SPDX-License-Identifier: Apache-2.0
+
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	xds "github.com/cncf/xds/go/xds/type/v3"
+	"go.yaml.in/yaml/v3"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/types/known/anypb"
+	"google.golang.org/protobuf/types/known/structpb"
+
+	wafv1 "coraza-waf/api/coraza/waf/v1"
+	"coraza-waf/internal/config"
+)
+
+const (
+	pluginName        = "coraza-waf"
+	typedStructSuffix = "/xds.type.v3.TypedStruct"
+	configTypeSuffix  = "/coraza.waf.v1.Config"
+)
+
+var errNoConfiguration = errors.New("no coraza-waf configuration found")
+
+// problem is an invalid configuration value at a line of the checked file.
+type problem struct {
+	line    int
+	source  string
+	message string
+}
+
+func (p problem) String() string {
+	return fmt.Sprintf("%s (%s)", p.message, p.source)
+}
+
+// checker collects the problems of all coraza-waf configurations in a file.
+type checker struct {
+	parser   config.Parser
+	problems []problem
+	found    int
+}
+
+// checkFile checks every YAML document of the file. A document is either a
+// bare plugin_config, with or without its "@type", or an envoy configuration
+// which is searched for coraza-waf filters and per route configurations.
+func checkFile(file string) ([]problem, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = f.Close() }()
+
+	c := &checker{parser: config.Parser{LocateBrokenDirectives: true}}
+	decoder := yaml.NewDecoder(f)
+	for {
+		var document yaml.Node
+		if err := decoder.Decode(&document); errors.Is(err, io.EOF) {
+			break
+		} else if err != nil {
+			return nil, err
+		}
+		if len(document.Content) == 0 {
+			continue
+		}
+		root := document.Content[0]
+		if isPluginConfig(root) {
+			c.check(root, "plugin_config", nil)
+			continue
+		}
+		c.walk(root, nil)
+	}
+	if c.found == 0 {
+		return nil, errNoConfiguration
+	}
+	return c.problems, nil
+}
+
+// isPluginConfig reports whether the node is a plugin_config itself rather than an envoy configuration.
+func isPluginConfig(node *yaml.Node) bool {
+	if typeURL := mappingValue(node, "@type"); typeURL != nil {
+		return strings.HasSuffix(typeURL.Value, typedStructSuffix) || strings.HasSuffix(typeURL.Value, configTypeSuffix)
+	}
+	return mappingValue(node, "directives") != nil || mappingValue(node, "default_directive") != nil
+}
+
+// walk searches the node for coraza-waf configurations. Per route
+// configurations are merged with the filter configuration of the enclosing
+// http connection manager, like envoy does.
+func (c *checker) walk(node *yaml.Node, listener *config.Configuration) {
+	switch node.Kind {
+	case yaml.SequenceNode:
+		for _, item := range node.Content {
+			c.walk(item, listener)
+		}
+	case yaml.MappingNode:
+		if httpFilters := mappingValue(node, "http_filters"); httpFilters != nil {
+			for _, filter := range findPluginConfigs(httpFilters) {
+				listener = c.check(filter, fmt.Sprintf("coraza-waf filter at line %d", filter.Line), nil)
+			}
+			for i := 0; i < len(node.Content); i += 2 {
+				if node.Content[i].Value != "http_filters" {
+					c.walk(node.Content[i+1], listener)
+				}
+			}
+			return
+		}
+		if pluginConfig := filterPluginConfig(node); pluginConfig != nil {
+			c.check(pluginConfig, fmt.Sprintf("coraza-waf filter at line %d", pluginConfig.Line), nil)
+			return
+		}
+		if plugins := mappingValue(node, "plugins_config"); plugins != nil {
+			if routeConfig := mappingValue(mappingValue(plugins, pluginName), "config"); routeConfig != nil {
+				c.check(routeConfig, fmt.Sprintf("per route configuration at line %d", routeConfig.Line), listener)
+			}
+			return
+		}
+		for i := 1; i < len(node.Content); i += 2 {
+			c.walk(node.Content[i], listener)
+		}
+	}
+}
+
+// findPluginConfigs returns the plugin_config of all coraza-waf filters in the http_filters list.
+func findPluginConfigs(node *yaml.Node) []*yaml.Node {
+	if pluginConfig := filterPluginConfig(node); pluginConfig != nil {
+		return []*yaml.Node{pluginConfig}
+	}
+	var pluginConfigs []*yaml.Node
+	for i, child := range node.Content {
+		if node.Kind == yaml.MappingNode && i%2 == 0 {
+			continue
+		}
+		pluginConfigs = append(pluginConfigs, findPluginConfigs(child)...)
+	}
+	return pluginConfigs
+}
+
+// filterPluginConfig returns the plugin_config if the node is the configuration of the coraza-waf golang filter.
+func filterPluginConfig(node *yaml.Node) *yaml.Node {
+	if name := mappingValue(node, "plugin_name"); name == nil || name.Value != pluginName {
+		return nil
+	}
+	return mappingValue(node, "plugin_config")
+}
+
+// check parses and compiles a single plugin configuration. An inheriting per
+// route configuration is merged with the listener configuration.
+func (c *checker) check(node *yaml.Node, source string, listener *config.Configuration) *config.Configuration {
+	c.found++
+	valueNode := node
+	typed := false
+	if typeURL := mappingValue(node, "@type"); typeURL != nil {
+		switch {
+		case strings.HasSuffix(typeURL.Value, typedStructSuffix):
+			valueNode = mappingValue(node, "value")
+		case strings.HasSuffix(typeURL.Value, configTypeSuffix):
+			// the fields of the typed message are next to its @type
+			typed = true
+		default:
+			c.report(typeURL.Line, source, fmt.Sprintf("unsupported type %s", typeURL.Value))
+			return nil
+		}
+	}
+	if valueNode == nil {
+		c.report(node.Line, source, "missing value")
+		return nil
+	}
+
+	var value map[string]interface{}
+	if err := valueNode.Decode(&value); err != nil {
+		c.report(valueNode.Line, source, err.Error())
+		return nil
+	}
+	delete(value, "@type")
+	convert := typedStructConfig
+	if typed {
+		convert = typedConfig
+	}
+	configAny, err := convert(value)
+	if err != nil {
+		c.report(valueNode.Line, source, err.Error())
+		return nil
+	}
+
+	parsed, err := c.parser.Parse(configAny, nil)
+	if err != nil {
+		c.reportErrors(valueNode, source, err)
+		return nil
+	}
+	configuration := parsed.(*config.Configuration)
+	if listener != nil {
+		merged, err := config.MergeConfigurations(listener, configuration)
+		if err != nil {
+			c.reportErrors(valueNode, source, err)
+		} else {
+			merged.Destroy()
+		}
+	}
+	return configuration
+}
+
+// typedStructConfig wraps the value of a TypedStruct like envoy does.
+func typedStructConfig(value map[string]interface{}) (*anypb.Any, error) {
+	configStruct, err := structpb.NewStruct(value)
+	if err != nil {
+		return nil, err
+	}
+	return anypb.New(&xds.TypedStruct{Value: configStruct})
+}
+
+// typedConfig converts the fields of a coraza.waf.v1.Config into the message like
+// envoy does, so unknown field names are rejected by protojson and the parser
+// validates the message with its protoc-gen-validate rules.
+func typedConfig(value map[string]interface{}) (*anypb.Any, error) {
+	configJSON, err := json.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+	message := &wafv1.Config{}
+	if err := protojson.Unmarshal(configJSON, message); err != nil {
+		return nil, fmt.Errorf("invalid coraza.waf.v1.Config: %w", err)
+	}
+	return anypb.New(message)
+}
+
+// reportErrors reports every aggregated error at the line of the value it refers to.
+func (c *checker) reportErrors(valueNode *yaml.Node, source string, err error) {
+	errs := []error{err}
+	if aggregated, ok := err.(interface{ Unwrap() []error }); ok {
+		errs = aggregated.Unwrap()
+	}
+	for _, err := range errs {
+		line := valueNode.Line
+		var pathErr *config.PathError
+		if errors.As(err, &pathErr) {
+			line = lookupLine(valueNode, pathErr.Path)
+		}
+		c.report(line, source, err.Error())
+	}
+}
+
+func (c *checker) report(line int, source string, message string) {
+	c.problems = append(c.problems, problem{line: line, source: source, message: message})
+}
+
+// lookupLine returns the line of the value at the configuration path, for
+// example directives["waf1"].simple_directives[2]. If the path does not exist
+// the line of the closest existing parent is returned.
+func lookupLine(node *yaml.Node, path string) int {
+	line := node.Line
+	for _, segment := range splitPath(path) {
+		switch node.Kind {
+		case yaml.MappingNode:
+			next := -1
+			for i := 0; i < len(node.Content) && next < 0; i += 2 {
+				if node.Content[i].Value == segment {
+					next = i
+				}
+			}
+			if next < 0 {
+				return line
+			}
+			line = node.Content[next].Line
+			node = node.Content[next+1]
+		case yaml.SequenceNode:
+			index, err := strconv.Atoi(segment)
+			if err != nil || index < 0 || index >= len(node.Content) {
+				return line
+			}
+			node = node.Content[index]
+			line = node.Line
+		default:
+			return line
+		}
+	}
+	return line
+}
+
+// splitPath splits a configuration path into its keys and list indexes.
+func splitPath(path string) []string {
+	var segments []string
+	for len(path) > 0 {
+		switch path[0] {
+		case '.':
+			path = path[1:]
+		case '[':
+			if strings.HasPrefix(path, `["`) {
+				quoted, err := strconv.QuotedPrefix(path[1:])
+				if err != nil {
+					return segments
+				}
+				key, _ := strconv.Unquote(quoted)
+				segments = append(segments, key)
+				path = strings.TrimPrefix(path[1+len(quoted):], "]")
+				continue
+			}
+			end := strings.IndexByte(path, ']')
+			if end < 0 {
+				return segments
+			}
+			segments = append(segments, path[1:end])
+			path = path[end+1:]
+		default:
+			end := strings.IndexAny(path, ".[")
+			if end < 0 {
+				end = len(path)
+			}
+			segments = append(segments, path[:end])
+			path = path[end:]
+		}
+	}
+	return segments
+}
+
+// mappingValue returns the value of the key if the node is a mapping containing it.
+func mappingValue(node *yaml.Node, key string) *yaml.Node {
+	if node == nil || node.Kind != yaml.MappingNode {
+		return nil
+	}
+	for i := 0; i < len(node.Content); i += 2 {
+		if node.Content[i].Value == key {
+			return node.Content[i+1]
+		}
+	}
+	return nil
+}
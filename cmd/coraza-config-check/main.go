@@ -0,0 +1,83 @@
+// Copyright © 2026 United Security Providers AG, Switzerland
+// SPDX-License-Identifier: Apache-2.0
+
+// coraza-config-check validates coraza-waf configurations without running envoy.
+//
+// It reads envoy configuration files or bare plugin_config files, runs every
+// coraza-waf filter and per route configuration through the same parser envoy
+// uses and compiles every directive set. All problems are reported with the
+// line they refer to and the command exits with a non-zero status code.
+//
+//	coraza-config-check [-v] <file>...
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/envoyproxy/envoy/contrib/golang/common/go/api"
+)
+
+const (
+	exitInvalid = 1
+	exitUsage   = 2
+)
+
+func main() {
+	verbose := flag.Bool("v", false, "print the log output of the configuration parser")
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "Usage: %s [-v] <file>...\n\n", os.Args[0])
+		fmt.Fprintln(flag.CommandLine.Output(), "Validates the coraza-waf configurations in envoy configuration or bare plugin_config files.")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	if flag.NArg() == 0 {
+		flag.Usage()
+		os.Exit(exitUsage)
+	}
+
+	// the parser logs through the envoy API, which is not available outside of envoy
+	api.SetCommonCAPI(&stderrCAPI{verbose: *verbose})
+
+	os.Exit(run(flag.Args(), os.Stdout, os.Stderr))
+}
+
+// run checks all files and returns the exit code. A file which can not be
+// read does not stop the check of the remaining files, it takes precedence
+// over invalid configurations in the exit code.
+func run(files []string, stdout io.Writer, stderr io.Writer) int {
+	code := 0
+	for _, file := range files {
+		problems, err := checkFile(file)
+		if err != nil {
+			fmt.Fprintf(stderr, "%s: %v\n", file, err)
+			code = exitUsage
+			continue
+		}
+		for _, p := range problems {
+			fmt.Fprintf(stdout, "%s:%d: %s\n", file, p.line, p)
+		}
+		if len(problems) > 0 && code == 0 {
+			code = exitInvalid
+		}
+	}
+	return code
+}
+
+// stderrCAPI replaces the envoy logging API. Log output is only printed in verbose mode.
+type stderrCAPI struct {
+	verbose bool
+}
+
+func (c *stderrCAPI) Log(level api.LogType, message string) {
+	fmt.Fprintf(os.Stderr, "[%s] %s\n", level, message)
+}
+
+func (c *stderrCAPI) LogLevel() api.LogType {
+	if c.verbose {
+		return api.Debug
+	}
+	return api.Critical
+}
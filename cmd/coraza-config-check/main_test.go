@@ -0,0 +1,201 @@
+//  Copyright © 2026 United Security Providers AG, Switzerland
+//  SPDX-License-Identifier: Apache-2.0
+
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+
+	"github.com/envoyproxy/envoy/contrib/golang/common/go/api"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMain(m *testing.M) {
+	api.SetCommonCAPI(&stderrCAPI{})
+	os.Exit(m.Run())
+}
+
+const validConfig = `directives:
+  waf1:
+    simple_directives:
+      - SecRuleEngine On
+default_directive: waf1
+`
+
+const brokenDirectiveConfig = `directives:
+  waf1:
+    simple_directives:
+      - SecRuleEngine On
+      - SecRule ARGS
+default_directive: waf1
+`
+
+const unknownKeyConfig = `directives:
+  waf1:
+    simple_directives:
+      - SecRuleEngine On
+default_directive: waf1
+host_directives_map: {}
+`
+
+const invalidPerRouteConfig = `static_resources:
+  listeners:
+  - filter_chains:
+    - filters:
+      - typed_config:
+          http_filters:
+          - typed_config:
+              plugin_name: coraza-waf
+              plugin_config:
+                "@type": type.googleapis.com/xds.type.v3.TypedStruct
+                value:
+                  directives:
+                    waf1:
+                      simple_directives:
+                      - SecRuleEngine On
+                  default_directive: waf1
+          route_config:
+            virtual_hosts:
+            - routes:
+              - typed_per_filter_config:
+                  golang:
+                    plugins_config:
+                      coraza-waf:
+                        config:
+                          "@type": type.googleapis.com/xds.type.v3.TypedStruct
+                          value:
+                            default_directive: waf2
+`
+
+const validTypedConfig = `"@type": type.googleapis.com/coraza.waf.v1.Config
+directives:
+  waf1:
+    simple_directives:
+      - SecRuleEngine On
+default_directive: waf1
+rules_reload_interval: 10s
+`
+
+const invalidTypedConfig = `"@type": type.googleapis.com/coraza.waf.v1.Config
+directives:
+  waf1:
+    simple_directives:
+      - SecRuleEngine On
+default_directive: waf1
+request_body_streaming_interval: 1024
+`
+
+const unknownFieldTypedConfig = `"@type": type.googleapis.com/coraza.waf.v1.Config
+directives:
+  waf1:
+    simple_directives:
+      - SecRuleEngine On
+default_directive: waf1
+host_directives_map: {}
+`
+
+const noConfig = `static_resources:
+  listeners: []
+`
+
+// missingFile is checked as a path which does not exist
+const missingFile = ""
+
+func TestRun(t *testing.T) {
+	tests := []struct {
+		name   string
+		files  []string
+		code   int
+		stdout []string
+		stderr []string
+	}{
+		{
+			name:  "valid configuration",
+			files: []string{validConfig},
+			code:  0,
+		},
+		{
+			name:   "broken directive",
+			files:  []string{brokenDirectiveConfig},
+			code:   exitInvalid,
+			stdout: []string{`file0.yaml:5: directives["waf1"].simple_directives[1]: waf init error`},
+		},
+		{
+			name:   "unknown key",
+			files:  []string{unknownKeyConfig},
+			code:   exitInvalid,
+			stdout: []string{"file0.yaml:6: host_directives_map: unknown key (plugin_config)"},
+		},
+		{
+			name:   "invalid per route configuration",
+			files:  []string{invalidPerRouteConfig},
+			code:   exitInvalid,
+			stdout: []string{"the referenced directive 'waf2' does not exist (per route configuration at line 25)"},
+		},
+		{
+			name:  "valid typed configuration",
+			files: []string{validTypedConfig},
+			code:  0,
+		},
+		{
+			name:   "typed configuration failing its validation rules",
+			files:  []string{invalidTypedConfig},
+			code:   exitInvalid,
+			stdout: []string{"RequestBodyStreamingInterval", "(plugin_config)"},
+		},
+		{
+			name:   "typed configuration with an unknown field",
+			files:  []string{unknownFieldTypedConfig},
+			code:   exitInvalid,
+			stdout: []string{"invalid coraza.waf.v1.Config", "host_directives_map"},
+		},
+		{
+			name:   "no configuration",
+			files:  []string{noConfig},
+			code:   exitUsage,
+			stderr: []string{"file0.yaml: no coraza-waf configuration found"},
+		},
+		{
+			name:   "unreadable file does not stop the check",
+			files:  []string{missingFile, unknownKeyConfig, validConfig},
+			code:   exitUsage,
+			stdout: []string{"file1.yaml:6: host_directives_map: unknown key"},
+			stderr: []string{"file0.yaml: open"},
+		},
+		{
+			name:   "problems of all files are reported",
+			files:  []string{brokenDirectiveConfig, unknownKeyConfig},
+			code:   exitInvalid,
+			stdout: []string{"file0.yaml:5:", "file1.yaml:6:"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			var files []string
+			for i, content := range tt.files {
+				file := filepath.Join(dir, "file"+strconv.Itoa(i)+".yaml")
+				if content != missingFile {
+					require.NoError(t, os.WriteFile(file, []byte(content), 0o600))
+				}
+				files = append(files, file)
+			}
+
+			var stdout, stderr bytes.Buffer
+			require.Equal(t, tt.code, run(files, &stdout, &stderr))
+			for _, expected := range tt.stdout {
+				require.Contains(t, stdout.String(), expected)
+			}
+			if len(tt.stdout) == 0 {
+				require.Empty(t, stdout.String())
+			}
+			for _, expected := range tt.stderr {
+				require.Contains(t, stderr.String(), expected)
+			}
+		})
+	}
+}
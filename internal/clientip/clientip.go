@@ -0,0 +1,134 @@
+// Copyright © 2026 United Security Providers AG, Switzerland
+// SPDX-License-Identifier: Apache-2.0
+
+// Package clientip resolves the address of the client of a request received
+// through trusted proxies from the header the proxies add.
+package clientip
+
+import (
+	"net/netip"
+	"strings"
+)
+
+// Header is the header the proxies pass the client address in.
+type Header string
+
+const (
+	HeaderXForwardedFor Header = "x-forwarded-for"
+	HeaderXRealIP       Header = "x-real-ip"
+	// HeaderForwarded is the Forwarded header of RFC 7239, the address is read from the for parameter
+	HeaderForwarded Header = "forwarded"
+)
+
+// Resolver resolves the client address of requests received from trusted proxies.
+type Resolver struct {
+	// Proxies are the networks of the trusted proxies, no proxy is trusted if empty
+	Proxies []netip.Prefix
+	Header  Header
+	// Hops is the position of the client address in the header, counted from the
+	// right end. 1 is the address added by the proxy connecting to Envoy.
+	Hops int
+}
+
+// ParseProxy parses a network in CIDR notation or a single address.
+func ParseProxy(proxy string) (netip.Prefix, error) {
+	if !strings.Contains(proxy, "/") {
+		addr, err := netip.ParseAddr(proxy)
+		if err != nil {
+			return netip.Prefix{}, err
+		}
+		return netip.PrefixFrom(addr, addr.BitLen()), nil
+	}
+	prefix, err := netip.ParsePrefix(proxy)
+	if err != nil {
+		return netip.Prefix{}, err
+	}
+	return prefix.Masked(), nil
+}
+
+// Trusted reports whether the peer address belongs to a trusted proxy.
+func (r Resolver) Trusted(peer string) bool {
+	addr, err := netip.ParseAddr(peer)
+	if err != nil {
+		return false
+	}
+	addr = addr.Unmap()
+	for _, proxy := range r.Proxies {
+		if proxy.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
+
+// Resolve returns the client address from the values of the header. The port
+// is 0 if the header does not contain it. ok is false if the header does not
+// contain a valid address at the configured position.
+func (r Resolver) Resolve(values []string) (ip string, port int, ok bool) {
+	var addresses []string
+	switch r.Header {
+	case HeaderXRealIP:
+		if len(values) == 0 {
+			return "", 0, false
+		}
+		// a single address, a proxy replaces the header instead of appending to it
+		addresses = []string{strings.TrimSpace(values[len(values)-1])}
+	case HeaderForwarded:
+		addresses = forwardedFor(values)
+	default:
+		for _, value := range values {
+			for _, address := range strings.Split(value, ",") {
+				addresses = append(addresses, strings.TrimSpace(address))
+			}
+		}
+	}
+	hops := max(r.Hops, 1)
+	if r.Header == HeaderXRealIP {
+		hops = 1
+	}
+	if len(addresses) < hops {
+		return "", 0, false
+	}
+	return ParseAddress(addresses[len(addresses)-hops])
+}
+
+// forwardedFor returns the for parameters of the elements of Forwarded headers.
+// An element without for parameter is returned as empty address, so it still counts as hop.
+func forwardedFor(values []string) []string {
+	var addresses []string
+	for _, value := range values {
+		for _, element := range strings.Split(value, ",") {
+			address := ""
+			for _, pair := range strings.Split(element, ";") {
+				key, value, found := strings.Cut(strings.TrimSpace(pair), "=")
+				if found && strings.EqualFold(strings.TrimSpace(key), "for") {
+					address = strings.Trim(strings.TrimSpace(value), `"`)
+				}
+			}
+			addresses = append(addresses, address)
+		}
+	}
+	return addresses
+}
+
+// ParseAddress parses an address with an optional port, IPv6 addresses with a port are enclosed in brackets.
+// Obfuscated identifiers of RFC 7239 like unknown or _hidden are not valid.
+func ParseAddress(address string) (string, int, bool) {
+	if addrPort, err := netip.ParseAddrPort(address); err == nil {
+		return addrPort.Addr().Unmap().String(), int(addrPort.Port()), true
+	}
+	address = strings.TrimSuffix(strings.TrimPrefix(address, "["), "]")
+	if addr, err := netip.ParseAddr(address); err == nil {
+		return addr.Unmap().String(), 0, true
+	}
+	return "", 0, false
+}
+
+// ValidHeader reports whether the header is supported.
+func ValidHeader(header string) bool {
+	switch Header(strings.ToLower(header)) {
+	case HeaderXForwardedFor, HeaderXRealIP, HeaderForwarded:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,335 @@
+// Copyright © 2026 United Security Providers AG, Switzerland
+// SPDX-License-Identifier: Apache-2.0
+
+package config
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"io/fs"
+	"maps"
+	"path"
+	"regexp"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/corazawaf/coraza/v3"
+
+	"coraza-waf/internal/datafile"
+	"coraza-waf/internal/logging"
+)
+
+// compiledWafs is the process wide cache of compiled WAFs. It is shared by all
+// configurations (listener, per virtual host and per route), so identical
+// directive sets are compiled only once.
+var compiledWafs = &wafCache{entries: make(map[wafCacheID]*wafCacheEntry)}
+
+var (
+	includeDirective = regexp.MustCompile(`(?im)^\s*Include\s+"?([^"\s]+)"?`)
+	fromFileOperator = regexp.MustCompile(`@(?:pmFromFile|pmf|ipMatchFromFile|ipMatchF)\s+"?([^"\s]+)"?`)
+)
+
+type wafCache struct {
+	mu      sync.Mutex
+	entries map[wafCacheID]*wafCacheEntry
+}
+
+// wafCacheID identifies a cache entry. Configurations reloading their
+// rules or data files at different intervals, or not at all, do not share a
+// WAF, so a WAF is only replaced or watched for the configurations which
+// enabled the reload.
+type wafCacheID struct {
+	key                    string
+	reloadInterval         time.Duration
+	dataFileReloadInterval time.Duration
+}
+
+// wafCacheEntry is a compiled WAF shared by all configurations with the same
+// directives. The WAF is replaced when referenced rule files change, see watch.
+type wafCacheEntry struct {
+	directives             string
+	key                    string
+	failedKey              string
+	reloadInterval         time.Duration
+	dataFileReloadInterval time.Duration
+	waf                    atomic.Pointer[coraza.WAF]
+	// scope watches the data files of the current WAF, nil without dataFileReloadInterval
+	scope *datafile.Scope
+	refs  int
+	stop  chan struct{}
+	// ready is closed once the WAF is compiled or err is set
+	ready chan struct{}
+	err   error
+}
+
+// load returns the current WAF, new transactions should always be created from the latest one.
+func (e *wafCacheEntry) load() coraza.WAF {
+	return *e.waf.Load()
+}
+
+// id returns the ID of the entry in the cache.
+func (e *wafCacheEntry) id() wafCacheID {
+	return wafCacheID{key: e.key, reloadInterval: e.reloadInterval, dataFileReloadInterval: e.dataFileReloadInterval}
+}
+
+// acquire returns the cache entry of the WAF compiled from the given directives.
+// The WAF is compiled if it is not cached yet. If reloadInterval is positive
+// the referenced rule files are watched for changes, if dataFileReloadInterval
+// is positive the data files of the operators are. Every successful call must
+// be paired with a call to release.
+//
+// The WAF is compiled without holding the lock of the cache, so other
+// directives can be acquired meanwhile. Concurrent calls for the same
+// directives wait for the first one to compile the WAF.
+func (c *wafCache) acquire(directives string, reloadInterval time.Duration, dataFileReloadInterval time.Duration) (*wafCacheEntry, error) {
+	watchDataFiles := dataFileReloadInterval > 0
+	if len(referencedFiles(directives, "", watchDataFiles)) == 0 {
+		// nothing to reload, the WAF can be shared with configurations without reload
+		reloadInterval = 0
+	}
+	id := wafCacheID{key: wafCacheKey(directives, watchDataFiles), reloadInterval: reloadInterval, dataFileReloadInterval: dataFileReloadInterval}
+
+	c.mu.Lock()
+	entry, ok := c.entries[id]
+	if ok {
+		entry.refs++
+		c.mu.Unlock()
+		<-entry.ready
+		if entry.err != nil {
+			return nil, entry.err
+		}
+		return entry, nil
+	}
+	entry = &wafCacheEntry{directives: directives, key: id.key, reloadInterval: reloadInterval, dataFileReloadInterval: dataFileReloadInterval, refs: 1, ready: make(chan struct{})}
+	c.entries[id] = entry
+	c.mu.Unlock()
+
+	waf, scope, err := compileWaf(directives, dataFileReloadInterval)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	defer close(entry.ready)
+	if err != nil {
+		// the waiting calls fail as well, a later call compiles again
+		entry.err = err
+		delete(c.entries, id)
+		return nil, err
+	}
+	entry.waf.Store(&waf)
+	entry.scope = scope
+	if reloadInterval > 0 {
+		entry.stop = make(chan struct{})
+		go c.watch(entry, entry.stop, reloadInterval)
+	}
+	return entry, nil
+}
+
+// retain adds a reference to an already cached WAF.
+func (c *wafCache) retain(entry *wafCacheEntry) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	entry.refs++
+}
+
+// release removes a reference, the WAF is dropped from the cache once it is not referenced anymore.
+func (c *wafCache) release(entry *wafCacheEntry) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	entry.refs--
+	if entry.refs > 0 {
+		return
+	}
+	if c.entries[entry.id()] == entry {
+		delete(c.entries, entry.id())
+	}
+	if entry.stop != nil {
+		close(entry.stop)
+		entry.stop = nil
+	}
+	entry.scope.Release()
+	entry.scope = nil
+}
+
+// watch polls the rule files referenced by the entry until it is released.
+// Polling the content instead of watching inotify events also notices the
+// symlink swaps kubernetes uses to update mounted ConfigMaps.
+func (c *wafCache) watch(entry *wafCacheEntry, stop <-chan struct{}, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+			c.reload(entry)
+		}
+	}
+}
+
+// reload recompiles the WAF of the entry if a referenced rule file changed.
+// Transactions which are already running keep using the previous WAF. If the
+// changed rules do not compile the previous WAF is kept.
+func (c *wafCache) reload(entry *wafCacheEntry) {
+	watchDataFiles := entry.dataFileReloadInterval > 0
+	key := wafCacheKey(entry.directives, watchDataFiles)
+	c.mu.Lock()
+	unchanged := key == entry.key || key == entry.failedKey
+	c.mu.Unlock()
+	if unchanged {
+		return
+	}
+
+	logger := logging.GetLogger().With("phase", "rules-reload", "files", referencedFiles(entry.directives, "", watchDataFiles))
+	waf, scope, err := compileWaf(entry.directives, entry.dataFileReloadInterval)
+	if err != nil {
+		c.mu.Lock()
+		entry.failedKey = key
+		c.mu.Unlock()
+		logger.Error("failed to reload changed rules, keeping the previous rules", "error", err.Error())
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if entry.refs <= 0 {
+		// released while compiling
+		scope.Release()
+		return
+	}
+	entry.waf.Store(&waf)
+	// running transactions of the previous WAF keep the last data of its files
+	entry.scope.Release()
+	entry.scope = scope
+	if c.entries[entry.id()] == entry {
+		delete(c.entries, entry.id())
+	}
+	entry.key = key
+	entry.failedKey = ""
+	if _, ok := c.entries[entry.id()]; !ok && entry.refs > 0 {
+		c.entries[entry.id()] = entry
+	}
+	logger.Info("reloaded changed rules")
+}
+
+// compileWaf compiles the directives. If dataFileReloadInterval is positive
+// the data files of the WAF are watched until the returned scope is released.
+func compileWaf(directives string, dataFileReloadInterval time.Duration) (coraza.WAF, *datafile.Scope, error) {
+	wafRoot := root
+	var scope *datafile.Scope
+	if dataFileReloadInterval > 0 {
+		scope = datafile.NewScope(root, dataFileReloadInterval)
+		wafRoot = scope
+	}
+	waf, err := coraza.NewWAF(coraza.NewWAFConfig().WithErrorCallback(errorCallback).WithRootFS(wafRoot).WithDirectives(directives))
+	if err != nil {
+		// the operators compiled before the error registered their files
+		scope.Release()
+		return nil, nil, err
+	}
+	return waf, scope, nil
+}
+
+// wafReferences tracks the cache entries of the directive sets of a configuration.
+// It is shared by copies of a configuration and released only once.
+type wafReferences struct {
+	entries map[string]*wafCacheEntry
+	once    sync.Once
+}
+
+// retain returns new references to the same WAFs, which have to be released independently.
+func (r *wafReferences) retain() *wafReferences {
+	if r == nil {
+		return nil
+	}
+	retained := &wafReferences{entries: maps.Clone(r.entries)}
+	for _, entry := range retained.entries {
+		compiledWafs.retain(entry)
+	}
+	return retained
+}
+
+func (r *wafReferences) lookup(name string) (*wafCacheEntry, bool) {
+	if r == nil {
+		return nil, false
+	}
+	entry, ok := r.entries[name]
+	return entry, ok
+}
+
+func (r *wafReferences) release() {
+	r.once.Do(func() {
+		for _, entry := range r.entries {
+			compiledWafs.release(entry)
+		}
+	})
+}
+
+// wafCacheKey hashes the directives together with the content of all
+// referenced files from the filesystem. This way a changed rule file results
+// in a new WAF, even if the directives themselves did not change.
+// Embedded files (prefixed with '@') can not change and are not read.
+// Watched data files are reloaded by the operators and are not hashed.
+func wafCacheKey(directives string, watchDataFiles bool) string {
+	hash := sha256.New()
+	hash.Write([]byte(directives))
+
+	visited := make(map[string]bool)
+	var hashReferencedFiles func(content string, dir string)
+	hashReferencedFiles = func(content string, dir string) {
+		for _, file := range referencedFiles(content, dir, watchDataFiles) {
+			if visited[file] {
+				continue
+			}
+			visited[file] = true
+			data, err := fs.ReadFile(root, file)
+			if err != nil {
+				// coraza reports the error when compiling the WAF
+				continue
+			}
+			hash.Write([]byte{0})
+			hash.Write([]byte(file))
+			hash.Write([]byte{0})
+			hash.Write(data)
+			hashReferencedFiles(string(data), path.Dir(file))
+		}
+	}
+	hashReferencedFiles(directives, "")
+
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+// referencedFiles returns the sorted filesystem paths of all files included or
+// loaded by operators in the given SecLang content. Watched data files are
+// reloaded by the operators themselves and are omitted, see datafile.Scope.
+func referencedFiles(content string, dir string, watchDataFiles bool) []string {
+	var files []string
+	references := [][][]string{includeDirective.FindAllStringSubmatch(content, -1)}
+	if !watchDataFiles {
+		references = append(references, fromFileOperator.FindAllStringSubmatch(content, -1))
+	}
+	for _, matches := range references {
+		for _, match := range matches {
+			file := match[1]
+			if strings.HasPrefix(file, "@") {
+				continue
+			}
+			if !path.IsAbs(file) && dir != "" {
+				file = path.Join(dir, file)
+			}
+			if strings.ContainsAny(file, "*?[") {
+				globbed, err := fs.Glob(root, file)
+				if err != nil {
+					continue
+				}
+				files = append(files, globbed...)
+				continue
+			}
+			files = append(files, file)
+		}
+	}
+	slices.Sort(files)
+	return slices.Compact(files)
+}
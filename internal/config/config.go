@@ -5,156 +5,635 @@
 package config
 
 import (
-	"errors"
 	"fmt"
+	"maps"
+	"math"
+	"net/netip"
 	"regexp"
+	"slices"
 	"strconv"
 	"strings"
+	"time"
 
-	xds "github.com/cncf/xds/go/xds/type/v3"
 	"github.com/corazawaf/coraza/v3"
 	ctypes "github.com/corazawaf/coraza/v3/types"
 	"github.com/envoyproxy/envoy/contrib/golang/common/go/api"
-	"github.com/google/uuid"
 	jsoniter "github.com/json-iterator/go"
 	"google.golang.org/protobuf/types/known/anypb"
 
+	"coraza-waf/internal/clientip"
+	"coraza-waf/internal/datafile"
+	"coraza-waf/internal/decompress"
+	"coraza-waf/internal/grpcbody"
 	"coraza-waf/internal/libinjection"
 	"coraza-waf/internal/logging"
 	"coraza-waf/internal/re2"
 )
 
-type Parser struct{}
+type Parser struct {
+	// LocateBrokenDirectives reports a directive set which fails to compile
+	// with the position of the broken simple directive. Locating it compiles
+	// the directive set repeatedly, it is meant for offline checks.
+	LocateBrokenDirectives bool
+}
 
 type Configuration struct {
-	directives       WafDirectives
-	DefaultDirective string
-	HostDirectiveMap HostDirectiveMap
-	WafMaps          WafMaps
-	LogFormat        logging.LogFormat
+	directives               WafDirectives
+	DefaultDirective         string
+	HostDirectiveMap         HostDirectiveMap
+	WildcardHostDirectiveMap WildcardHostDirectiveMap
+	RouteDirectiveMap        RouteDirectiveMap
+	LogFormat                logging.LogFormat
+	GrpcDescriptors          *grpcbody.Descriptors
+	WebsocketDirective       string
+	sseEventInspection       *bool
+	requestBodyStreaming     *int
+	responseDecompression    *decompress.Limits
+	requestDecompression     *RequestDecompression
+	trustedProxies           []netip.Prefix
+	clientIPHeader           clientip.Header
+	clientIPHops             int
+	ClientAddressFilterState string
+	inherits                 bool
+	wafRefs                  *wafReferences
 }
 
-type WafMaps map[string]coraza.WAF
-
 type WafDirectives map[string]Directives
 
 type Directives struct {
-	SimpleDirectives []string `json:"simple_directives"`
+	SimpleDirectives []string       `json:"simple_directives"`
+	Extends          string         `json:"extends"`
+	BlockResponse    *BlockResponse `json:"block_response"`
 }
 
 type HostDirectiveMap map[string]string
 
+// UnsupportedEncodingPolicy is the handling of request bodies with an encoding that can not be decompressed.
+type UnsupportedEncodingPolicy string
+
+const (
+	UnsupportedEncodingReject     UnsupportedEncodingPolicy = "reject"
+	UnsupportedEncodingPass       UnsupportedEncodingPolicy = "pass"
+	UnsupportedEncodingInspectRaw UnsupportedEncodingPolicy = "inspect_raw"
+)
+
+// RequestDecompression is the decompression of compressed request bodies.
+type RequestDecompression struct {
+	MaxRatio            int
+	UnsupportedEncoding UnsupportedEncodingPolicy
+}
+
 var filePathPrefix = regexp.MustCompile(".*/")
 var maxMessageSize = 250
+
+// minReloadInterval prevents polling the rule files in a busy loop
+const minReloadInterval = time.Second
+
+// minRequestBodyStreamingInterval prevents evaluating the rules for every few bytes of a request body
+const minRequestBodyStreamingInterval = 4096
+
 var logFormat = logging.FormatText
 
 func (p Parser) Parse(any *anypb.Any, callbacks api.ConfigCallbackHandler) (any, error) {
-	configStruct := &xds.TypedStruct{}
-	if err := any.UnmarshalTo(configStruct); err != nil {
+	v, err := configValue(any)
+	if err != nil {
 		return nil, err
 	}
-	v := configStruct.Value
+	// validate the structure first, this rejects unknown keys and wrong types.
+	// The parsing continues to report all problems at once, values of the wrong
+	// type were already reported and are skipped below
+	errs := configSchema.validate("", v)
 	var config Configuration
-	json := jsoniter.ConfigCompatibleWithStandardLibrary
 
-	if useRe2, ok := v.AsMap()["use_re2"].(bool); !ok || useRe2 {
+	if useRe2, ok := v["use_re2"].(bool); !ok || useRe2 {
 		re2.Register()
 	}
 
-	if useLibinjection, ok := v.AsMap()["use_libinjection"].(bool); !ok || useLibinjection {
+	if useLibinjection, ok := v["use_libinjection"].(bool); !ok || useLibinjection {
 		libinjection.Register()
 	}
 
-	if directivesRaw, ok := v.AsMap()["directives"].(map[string]interface{}); ok {
-		var wafDirectives WafDirectives
-		directivesJSON, err := json.Marshal(directivesRaw)
-		if err != nil {
-			return nil, fmt.Errorf("failed to marshal directives: %w", err)
-		}
-		err = json.Unmarshal(directivesJSON, &wafDirectives)
-		if err != nil {
-			return nil, fmt.Errorf("failed to parse directives: %w", err)
+	// directives is optional for per route configurations, a configuration
+	// without directives inherits the directive sets of its parent, see Merge
+	if directivesRaw, ok := v["directives"].(map[string]interface{}); ok {
+		wafDirectives := parseDirectives(directivesRaw)
+		if len(directivesRaw) == 0 {
+			errs = append(errs, pathErrorf("directives", "must not be empty"))
 		}
-		if len(wafDirectives) == 0 {
-			return nil, errors.New("directives is empty")
+		for _, name := range slices.Sorted(maps.Keys(wafDirectives)) {
+			if blockResponse := wafDirectives[name].BlockResponse; blockResponse != nil {
+				errs = append(errs, blockResponse.compile(fmt.Sprintf("directives[%q].block_response", name))...)
+			}
 		}
+		wafDirectives, extendsErrs := resolveExtends(wafDirectives)
+		errs = append(errs, extendsErrs...)
 		config.directives = wafDirectives
+	} else {
+		config.inherits = true
+	}
+	if defaultDirectiveString, ok := v["default_directive"].(string); ok {
+		config.DefaultDirective = defaultDirectiveString
+	} else if !config.inherits {
+		errs = append(errs, pathErrorf("default_directive", "is required"))
+	}
 
-		// parse the WAFs into config.wafMaps in any case
-		wafMaps := make(WafMaps)
-		for wafName, wafRules := range config.directives {
-			wafConfig := coraza.NewWAFConfig().WithErrorCallback(errorCallback).WithRootFS(root).WithDirectives(strings.Join(wafRules.SimpleDirectives, "\n"))
-			waf, err := coraza.NewWAF(wafConfig)
+	// read host_directives_map as a YAML map
+	if hostDirectiveMapRaw, ok := v["host_directive_map"].(map[string]interface{}); ok {
+		hostDirectiveMap := make(HostDirectiveMap, len(hostDirectiveMapRaw))
+		wildcardHostDirectiveMap := make(WildcardHostDirectiveMap)
+		for _, host := range slices.Sorted(maps.Keys(hostDirectiveMapRaw)) {
+			key, wildcard, err := parseHostKey(host)
 			if err != nil {
-				return nil, fmt.Errorf("%s mapping waf init error:%s", wafName, err.Error())
+				errs = append(errs, &PathError{Path: fmt.Sprintf("host_directive_map[%q]", host), Err: err})
+				continue
+			}
+			directive, ok := hostDirectiveMapRaw[host].(string)
+			if !ok {
+				continue
+			}
+			if wildcard {
+				wildcardHostDirectiveMap[key] = directive
+			} else {
+				hostDirectiveMap[key] = directive
 			}
-			wafMaps[wafName] = waf
 		}
-		config.WafMaps = wafMaps
-	} else {
-		return nil, errors.New("directives does not exist")
+		config.HostDirectiveMap = hostDirectiveMap
+		config.WildcardHostDirectiveMap = wildcardHostDirectiveMap
+	} else if !config.inherits {
+		// host_directives_map is not set, however we still need to initialize an empty host mapping
+		config.HostDirectiveMap = make(HostDirectiveMap)
+		config.WildcardHostDirectiveMap = make(WildcardHostDirectiveMap)
 	}
-	if defaultDirectiveString, ok := v.AsMap()["default_directive"].(string); ok {
-		_, ok := config.directives[defaultDirectiveString]
-		if !ok {
-			return nil, errors.New("the referenced default_directive does not exist in directives")
+
+	// websocket_directive is optional, without it the messages of WebSocket connections are not inspected
+	if websocketDirective, ok := v["websocket_directive"].(string); ok {
+		config.WebsocketDirective = websocketDirective
+	}
+
+	// sse_event_inspection is optional, without it event streams are inspected like any other response
+	if sseEventInspection, ok := v["sse_event_inspection"].(bool); ok {
+		config.sseEventInspection = &sseEventInspection
+	}
+
+	// request_body_streaming_interval is optional, without it the request body is buffered until it is complete
+	if interval, ok := v["request_body_streaming_interval"].(float64); ok {
+		if interval != 0 && (interval < minRequestBodyStreamingInterval || interval > math.MaxUint32) {
+			errs = append(errs, pathErrorf("request_body_streaming_interval", "must be 0 or between %d and %d bytes", minRequestBodyStreamingInterval, uint32(math.MaxUint32)))
 		}
-		config.DefaultDirective = defaultDirectiveString
-	} else {
-		return nil, errors.New("default_directive does not exist")
+		requestBodyStreaming := int(interval)
+		config.requestBodyStreaming = &requestBodyStreaming
 	}
 
-	// host_directives_map is not set, however we still need to initialize an empty host mapping
-	if v.AsMap()["host_directive_map"] == nil {
-		hostDirectiveMap := make(HostDirectiveMap)
-		config.HostDirectiveMap = hostDirectiveMap
+	// response_decompression is optional, without it the default limits are used
+	if decompression, ok := v["response_decompression"].(map[string]interface{}); ok {
+		limits, limitErrs := parseDecompressionLimits("response_decompression", decompression)
+		errs = append(errs, limitErrs...)
+		config.responseDecompression = &limits
+	}
 
-	} else {
-		// read host_directives_map as a YAML map
-		if hostDirectiveMapRaw, ok := v.AsMap()["host_directive_map"].(map[string]interface{}); ok {
-			hostDirectiveMap := make(HostDirectiveMap, len(hostDirectiveMapRaw))
-			for host, ruleRaw := range hostDirectiveMapRaw {
-				rule, ok := ruleRaw.(string)
-				if !ok {
-					return nil, fmt.Errorf("host_directive_map value for '%s' must be a string", host)
-				}
-				_, ok = config.directives[rule]
-				if !ok {
-					return nil, fmt.Errorf("the referenced directive '%s' for host %s does not exist", rule, host)
-				}
-				hostDirectiveMap[host] = rule
+	// request_decompression is optional, without it the defaults are used
+	if decompression, ok := v["request_decompression"].(map[string]interface{}); ok {
+		requestDecompression := RequestDecompression{MaxRatio: decompress.DefaultMaxRatio, UnsupportedEncoding: UnsupportedEncodingInspectRaw}
+		if maxRatio, ok := decompression["max_ratio"].(float64); ok && maxRatio != 0 {
+			if maxRatio < 1 || maxRatio > math.MaxUint32 {
+				errs = append(errs, pathErrorf("request_decompression.max_ratio", "must be between 1 and %d", uint32(math.MaxUint32)))
+			}
+			requestDecompression.MaxRatio = int(maxRatio)
+		}
+		if policy, ok := decompression["unsupported_encoding"].(string); ok && policy != "" {
+			switch policy := UnsupportedEncodingPolicy(strings.ToLower(policy)); policy {
+			case UnsupportedEncodingReject, UnsupportedEncodingPass, UnsupportedEncodingInspectRaw:
+				requestDecompression.UnsupportedEncoding = policy
+			default:
+				errs = append(errs, pathErrorf("request_decompression.unsupported_encoding", "invalid value '%s'. Only '%s', '%s' and '%s' are supported", policy, UnsupportedEncodingReject, UnsupportedEncodingPass, UnsupportedEncodingInspectRaw))
+			}
+		}
+		config.requestDecompression = &requestDecompression
+	}
+
+	// trusted_proxies is optional, without it the peer address is the client address
+	if proxies, ok := v["trusted_proxies"].([]interface{}); ok {
+		config.trustedProxies = make([]netip.Prefix, 0, len(proxies))
+		for i, proxyRaw := range proxies {
+			proxy, ok := proxyRaw.(string)
+			if !ok {
+				continue
+			}
+			prefix, err := clientip.ParseProxy(proxy)
+			if err != nil {
+				errs = append(errs, pathErrorf(fmt.Sprintf("trusted_proxies[%d]", i), "invalid network '%s'", proxy))
+				continue
 			}
-			config.HostDirectiveMap = hostDirectiveMap
-		} else {
-			return nil, errors.New("host_directive_map must be a map of host to directive name")
+			config.trustedProxies = append(config.trustedProxies, prefix)
+		}
+	}
+	if header, ok := v["client_ip_header"].(string); ok && header != "" {
+		if !clientip.ValidHeader(header) {
+			errs = append(errs, pathErrorf("client_ip_header", "invalid value '%s'. Only '%s', '%s' and '%s' are supported", header, clientip.HeaderXForwardedFor, clientip.HeaderXRealIP, clientip.HeaderForwarded))
 		}
+		config.clientIPHeader = clientip.Header(strings.ToLower(header))
+	}
+	if hops, ok := v["client_ip_hops"].(float64); ok && hops != 0 {
+		if hops < 1 || hops > math.MaxUint32 {
+			errs = append(errs, pathErrorf("client_ip_hops", "must be between 1 and %d", uint32(math.MaxUint32)))
+		}
+		config.clientIPHops = int(hops)
+	}
+
+	// client_address_filter_state is optional, without it connections without IP address use a placeholder address
+	if key, ok := v["client_address_filter_state"].(string); ok {
+		config.ClientAddressFilterState = key
+	}
+
+	// route_directive_map is optional, an empty list never matches
+	if routes, ok := v["route_directive_map"].([]interface{}); ok {
+		routeDirectiveMap, routeErrs := parseRouteDirectiveMap(routes)
+		errs = append(errs, routeErrs...)
+		config.RouteDirectiveMap = routeDirectiveMap
+	}
+
+	// the references of an inheriting configuration are validated when it is merged with its parent
+	if !config.inherits {
+		errs = append(errs, config.validateReferences()...)
 	}
 
 	logging.Init(logging.FormatText)
 	logger := logging.GetLogger().With("phase", "config-parsing")
 	// read log format
-	if logFormatString, ok := v.AsMap()["log_format"].(string); ok {
+	if logFormatString, ok := v["log_format"].(string); ok {
 		switch format := logging.LogFormat(strings.ToLower(logFormatString)); format {
 		case logging.FormatJson, logging.FormatText, logging.FormatFtw:
 			config.LogFormat = format
 		default:
-			return nil, fmt.Errorf("invalid log_format. Only '%s' and '%s' is supported", logging.FormatJson, logging.FormatText)
+			errs = append(errs, pathErrorf("log_format", "invalid value '%s'. Only '%s' and '%s' is supported", logFormatString, logging.FormatJson, logging.FormatText))
 		}
-	} else {
+	} else if !config.inherits {
 		config.LogFormat = logging.FormatText
 		logger.Info("No log_format provided. Using default 'text'")
 	}
 
-	logFormat = config.LogFormat
+	// rules_reload_interval is optional, without it changed rule files are only picked up by a configuration update
+	var reloadInterval time.Duration
+	if reloadIntervalString, ok := v["rules_reload_interval"].(string); ok {
+		reloadInterval, err = time.ParseDuration(reloadIntervalString)
+		if err != nil {
+			errs = append(errs, pathErrorf("rules_reload_interval", "invalid duration '%s'", reloadIntervalString))
+		} else if reloadInterval < minReloadInterval {
+			errs = append(errs, pathErrorf("rules_reload_interval", "must be at least %s", minReloadInterval))
+		}
+	}
+
+	// data_file_reload_interval is optional, without it data files are only read when a WAF is compiled
+	var dataFileReloadInterval time.Duration
+	if reloadIntervalString, ok := v["data_file_reload_interval"].(string); ok {
+		dataFileReloadInterval, err = time.ParseDuration(reloadIntervalString)
+		if err != nil {
+			errs = append(errs, pathErrorf("data_file_reload_interval", "invalid duration '%s'", reloadIntervalString))
+		} else if dataFileReloadInterval < minReloadInterval {
+			errs = append(errs, pathErrorf("data_file_reload_interval", "must be at least %s", minReloadInterval))
+		}
+	}
+
+	// an inheriting configuration compiles no directive sets, it can not reload them
+	if config.inherits {
+		for _, key := range []string{"rules_reload_interval", "data_file_reload_interval"} {
+			if _, ok := v[key]; ok {
+				errs = append(errs, pathErrorf(key, "is only valid with directives, inherited directive sets are reloaded as configured by the parent configuration"))
+			}
+		}
+	}
+
+	// grpc_descriptor_set is optional, without it gRPC messages are inspected as raw protobuf
+	if descriptorSetPath, ok := v["grpc_descriptor_set"].(string); ok {
+		config.GrpcDescriptors, err = grpcbody.LoadDescriptors(descriptorSetPath)
+		if err != nil {
+			errs = append(errs, pathErrorf("grpc_descriptor_set", "failed to load '%s': %w", descriptorSetPath, err))
+		}
+	}
+
+	// the operators have to be replaced before the WAFs using them are compiled
+	if dataFileReloadInterval > 0 && len(errs) == 0 {
+		datafile.Register()
+	}
+
+	// compile the WAFs last, also for an invalid configuration to report broken
+	// directives with the other problems. The cache references are released again
+	// if the configuration is invalid.
+	// Identical directive sets are compiled only once and shared with other configurations
+	wafRefs := &wafReferences{entries: make(map[string]*wafCacheEntry)}
+	for _, wafName := range config.compiledDirectives() {
+		entry, err := compiledWafs.acquire(strings.Join(config.directives[wafName].SimpleDirectives, "\n"), reloadInterval, dataFileReloadInterval)
+		if err != nil {
+			// a broken inherited directive fails every directive set extending it, report it only once
+			err = config.directiveError(wafName, err, p.LocateBrokenDirectives)
+			if !slices.ContainsFunc(errs, func(e error) bool { return e.Error() == err.Error() }) {
+				errs = append(errs, err)
+			}
+			continue
+		}
+		wafRefs.entries[wafName] = entry
+	}
+	if len(errs) > 0 {
+		wafRefs.release()
+		return nil, configErrors(errs)
+	}
+	config.wafRefs = wafRefs
+
+	if config.LogFormat != "" {
+		logFormat = config.LogFormat
+	}
 	return &config, nil
 }
 
+// parseDirectives reads the directive sets. Values of the wrong type were reported by the schema validation and are skipped.
+func parseDirectives(directivesRaw map[string]interface{}) WafDirectives {
+	json := jsoniter.ConfigCompatibleWithStandardLibrary
+	wafDirectives := make(WafDirectives, len(directivesRaw))
+	for name, directiveSetRaw := range directivesRaw {
+		directiveSet, ok := directiveSetRaw.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		var directives Directives
+		if simpleDirectives, ok := directiveSet["simple_directives"].([]interface{}); ok {
+			for _, directiveRaw := range simpleDirectives {
+				if directive, ok := directiveRaw.(string); ok {
+					directives.SimpleDirectives = append(directives.SimpleDirectives, directive)
+				}
+			}
+		}
+		directives.Extends, _ = directiveSet["extends"].(string)
+		if blockResponseRaw, ok := directiveSet["block_response"].(map[string]interface{}); ok {
+			var blockResponse BlockResponse
+			blockResponseJSON, err := json.Marshal(blockResponseRaw)
+			if err == nil && json.Unmarshal(blockResponseJSON, &blockResponse) == nil {
+				directives.BlockResponse = &blockResponse
+			}
+		}
+		wafDirectives[name] = directives
+	}
+	return wafDirectives
+}
+
+// parseDecompressionLimits reads the limits of a decompression object, unset limits use the defaults.
+func parseDecompressionLimits(path string, v map[string]interface{}) (decompress.Limits, []error) {
+	var errs []error
+	limits := decompress.Limits{MaxSize: decompress.DefaultMaxSize, MaxRatio: decompress.DefaultMaxRatio}
+	for _, field := range []struct {
+		key   string
+		limit *int
+	}{{"max_size", &limits.MaxSize}, {"max_ratio", &limits.MaxRatio}} {
+		value, ok := v[field.key].(float64)
+		if !ok || value == 0 {
+			continue
+		}
+		if value < 1 || value > math.MaxUint32 {
+			errs = append(errs, pathErrorf(joinPath(path, field.key), "must be between 1 and %d", uint32(math.MaxUint32)))
+			continue
+		}
+		*field.limit = int(value)
+	}
+	return limits, errs
+}
+
+// validateReferences makes sure all directive names referenced by the configuration exist.
+func (c *Configuration) validateReferences() []error {
+	var errs []error
+	check := func(path string, name string) {
+		if err := c.checkReference(path, name); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	if c.DefaultDirective != "" {
+		check("default_directive", c.DefaultDirective)
+	}
+	for _, host := range slices.Sorted(maps.Keys(c.HostDirectiveMap)) {
+		check(fmt.Sprintf("host_directive_map[%q]", host), c.HostDirectiveMap[host])
+	}
+	for _, host := range slices.Sorted(maps.Keys(c.WildcardHostDirectiveMap)) {
+		check(fmt.Sprintf("host_directive_map[%q]", "*"+host), c.WildcardHostDirectiveMap[host])
+	}
+	if c.WebsocketDirective != "" {
+		check("websocket_directive", c.WebsocketDirective)
+	}
+	for i, route := range c.RouteDirectiveMap {
+		check(fmt.Sprintf("route_directive_map[%d].directive", i), route.Directive)
+	}
+	return errs
+}
+
+// checkReference makes sure a referenced directive set exists. Once the WAFs are
+// compiled, i.e. when an inheriting configuration is merged, the directive set
+// must also be compiled: a set only used through extends is not compiled on its own.
+func (c *Configuration) checkReference(path string, name string) error {
+	if !c.hasDirective(name) {
+		return pathErrorf(path, "the referenced directive '%s' does not exist", name)
+	}
+	if c.wafRefs != nil {
+		if _, ok := c.wafRefs.lookup(name); !ok {
+			return pathErrorf(path, "the referenced directive '%s' is only used through extends and is not compiled, reference it in the parent configuration", name)
+		}
+	}
+	return nil
+}
+
+// compiledDirectives returns the names of the directive sets to compile. A set
+// only used as the base of other sets through extends is not compiled on its
+// own, its directives are compiled with the sets extending it.
+func (c *Configuration) compiledDirectives() []string {
+	referenced := map[string]bool{c.DefaultDirective: true, c.WebsocketDirective: true}
+	for _, name := range c.HostDirectiveMap {
+		referenced[name] = true
+	}
+	for _, name := range c.WildcardHostDirectiveMap {
+		referenced[name] = true
+	}
+	for _, route := range c.RouteDirectiveMap {
+		referenced[route.Directive] = true
+	}
+	extended := map[string]bool{}
+	for _, directives := range c.directives {
+		extended[directives.Extends] = true
+	}
+	var names []string
+	for _, name := range slices.Sorted(maps.Keys(c.directives)) {
+		if referenced[name] || !extended[name] {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// Waf returns the WAF of the named directive set or nil if the configuration does not compile it.
+// The WAF is replaced when the rule files it loads are reloaded, so it must be looked up for every new stream.
+func (c *Configuration) Waf(name string) coraza.WAF {
+	entry, ok := c.wafRefs.lookup(name)
+	if !ok {
+		return nil
+	}
+	return entry.load()
+}
+
+// InspectSSEEvents reports whether the events of a text/event-stream response are inspected one by one.
+func (c *Configuration) InspectSSEEvents() bool {
+	return c.sseEventInspection != nil && *c.sseEventInspection
+}
+
+// RequestBodyStreamingInterval returns the number of request body bytes after which the
+// received data is inspected and passed upstream, or 0 if the request body is buffered.
+func (c *Configuration) RequestBodyStreamingInterval() int {
+	if c.requestBodyStreaming == nil {
+		return 0
+	}
+	return *c.requestBodyStreaming
+}
+
+// ResponseDecompressionLimits returns the limits of the decompression of compressed response bodies.
+func (c *Configuration) ResponseDecompressionLimits() decompress.Limits {
+	if c.responseDecompression == nil {
+		return decompress.Limits{MaxSize: decompress.DefaultMaxSize, MaxRatio: decompress.DefaultMaxRatio}
+	}
+	return *c.responseDecompression
+}
+
+// RequestDecompression returns the decompression of compressed request bodies.
+func (c *Configuration) RequestDecompression() RequestDecompression {
+	if c.requestDecompression == nil {
+		return RequestDecompression{MaxRatio: decompress.DefaultMaxRatio, UnsupportedEncoding: UnsupportedEncodingInspectRaw}
+	}
+	return *c.requestDecompression
+}
+
+// ClientIP returns the resolver of the client address of requests received from trusted proxies.
+func (c *Configuration) ClientIP() clientip.Resolver {
+	resolver := clientip.Resolver{Proxies: c.trustedProxies, Header: c.clientIPHeader, Hops: c.clientIPHops}
+	if resolver.Header == "" {
+		resolver.Header = clientip.HeaderXForwardedFor
+	}
+	if resolver.Hops == 0 {
+		resolver.Hops = 1
+	}
+	return resolver
+}
+
+// BlockResponse returns the block response of the named directive set, or nil if it uses the default empty response.
+func (c *Configuration) BlockResponse(name string) *BlockResponse {
+	return c.directives[name].BlockResponse
+}
+
+func (c *Configuration) hasDirective(name string) bool {
+	_, ok := c.directives[name]
+	return ok
+}
+
+// Destroy is called by envoy when the configuration is deleted due to an update
+// or removal of the plugin. It releases the references to the cached WAFs.
+func (c *Configuration) Destroy() {
+	if c.wafRefs != nil {
+		c.wafRefs.release()
+	}
+}
+
+// Merge merges a per route or per virtual host configuration into its parent.
+// A child configuration with its own directives replaces the parent configuration.
+// A child configuration without directives inherits the compiled directive sets
+// of the parent and only overrides the options it sets itself.
+// Envoy destroys the merged configuration independently of the parent and the
+// child, so a new configuration with its own references to the WAFs is returned.
 func (p Parser) Merge(parentConfig any, childConfig any) any {
-	// simply return the child config
-	return childConfig
+	child, ok := childConfig.(*Configuration)
+	if !ok {
+		return childConfig
+	}
+	parent, ok := parentConfig.(*Configuration)
+	if !ok {
+		parent = &Configuration{}
+	}
+	merged, err := MergeConfigurations(parent, child)
+	if err != nil {
+		logging.GetLogger().With("phase", "config-merging").Error("invalid per route configuration, rejecting the requests of the route", "error", err.Error())
+		return rejectingConfiguration(parent)
+	}
+	return merged
+}
+
+// rejectingConfiguration returns a configuration without directive sets for an
+// invalid per route configuration. The filter rejects its requests with status
+// 500 instead of passing them with the directives of the parent.
+func rejectingConfiguration(parent *Configuration) *Configuration {
+	return &Configuration{LogFormat: parent.LogFormat}
+}
+
+// MergeConfigurations merges an inheriting child configuration into its parent, see Parser.Merge.
+// It fails if the merged configuration references a directive set the parent does not define.
+// The returned configuration holds its own references to the WAFs and has to be destroyed.
+func MergeConfigurations(parent *Configuration, child *Configuration) (*Configuration, error) {
+	if !child.inherits {
+		merged := *child
+		merged.wafRefs = child.wafRefs.retain()
+		return &merged, nil
+	}
+	merged := *parent
+	if child.DefaultDirective != "" {
+		merged.DefaultDirective = child.DefaultDirective
+	}
+	if child.HostDirectiveMap != nil {
+		merged.HostDirectiveMap = child.HostDirectiveMap
+		merged.WildcardHostDirectiveMap = child.WildcardHostDirectiveMap
+	}
+	if child.RouteDirectiveMap != nil {
+		merged.RouteDirectiveMap = child.RouteDirectiveMap
+	}
+	if child.LogFormat != "" {
+		merged.LogFormat = child.LogFormat
+	}
+	if child.WebsocketDirective != "" {
+		merged.WebsocketDirective = child.WebsocketDirective
+	}
+	if child.sseEventInspection != nil {
+		merged.sseEventInspection = child.sseEventInspection
+	}
+	if child.requestBodyStreaming != nil {
+		merged.requestBodyStreaming = child.requestBodyStreaming
+	}
+	if child.responseDecompression != nil {
+		merged.responseDecompression = child.responseDecompression
+	}
+	if child.requestDecompression != nil {
+		merged.requestDecompression = child.requestDecompression
+	}
+	if child.trustedProxies != nil {
+		merged.trustedProxies = child.trustedProxies
+	}
+	if child.clientIPHeader != "" {
+		merged.clientIPHeader = child.clientIPHeader
+	}
+	if child.clientIPHops != 0 {
+		merged.clientIPHops = child.clientIPHops
+	}
+	if child.ClientAddressFilterState != "" {
+		merged.ClientAddressFilterState = child.ClientAddressFilterState
+	}
+	if child.GrpcDescriptors != nil {
+		merged.GrpcDescriptors = child.GrpcDescriptors
+	}
+	if errs := merged.validateReferences(); len(errs) > 0 {
+		return nil, configErrors(errs)
+	}
+	// the merged configuration is destroyed independently of its parent
+	merged.wafRefs = parent.wafRefs.retain()
+	return &merged, nil
 }
 
 func errorCallback(error ctypes.MatchedRule) {
+	// the rule only carries the ID of the transaction, the filter registered the
+	// request ID and route context with it, see filter.go
+	// see https://github.com/corazawaf/coraza/discussions/1186
+	transaction := lookupTransaction(error.TransactionID())
+	if transaction.requestHeadersLogged && error.Rule().Phase() == ctypes.PhaseRequestHeaders {
+		return
+	}
+
 	// FTW has its own log format because they expect the log to be formatted
 	// in a specific way. Coraza already has a method that formats it correctly.
 
@@ -176,15 +655,8 @@ func errorCallback(error ctypes.MatchedRule) {
 		return
 	}
 
-	// the transaction ID was set to the request ID on transaction initalization, see filter.go
-	// see https://github.com/corazawaf/coraza/discussions/1186
-	xReqID := error.TransactionID()
 	category := ""
 
-	if err := uuid.Validate(xReqID); err != nil {
-		// the request ID was not available and coraza has choosen a random ID
-		xReqID = ""
-	}
 	// determine category from configuration file information
 	cfi := filePathPrefix.ReplaceAllString(error.Rule().File(), "")
 	cfi = strings.ReplaceAll(cfi, ".conf", "")
@@ -218,7 +690,7 @@ func errorCallback(error ctypes.MatchedRule) {
 		"hostname", error.ServerIPAddress(),
 		"uri", error.URI(),
 		"client", error.ClientIPAddress(),
-		"request-id", xReqID,
+		"request-id", transaction.requestID,
 	)
 	logger = logger.WithGroup("crs").With(
 		"version", rule.Version(),
@@ -237,6 +709,8 @@ func errorCallback(error ctypes.MatchedRule) {
 		"category", category,
 		"tags", rule.Tags(),
 	)
+	// the route context allows exclusions per route
+	logger = logger.With(transaction.route.LogArgs()...)
 
 	logger = logger.WithGroup("match").With(
 		"name", matchData.Variable().Name(),
@@ -0,0 +1,224 @@
+//  Copyright © 2026 United Security Providers AG, Switzerland
+//  SPDX-License-Identifier: Apache-2.0
+
+package config
+
+import (
+	"errors"
+	"os"
+	"testing"
+
+	xds "github.com/cncf/xds/go/xds/type/v3"
+	"github.com/envoyproxy/envoy/contrib/golang/common/go/api"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/types/known/anypb"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+// discardCAPI replaces the envoy logging API, which is not available outside of envoy.
+type discardCAPI struct{}
+
+func (discardCAPI) Log(api.LogType, string) {}
+
+func (discardCAPI) LogLevel() api.LogType { return api.Critical }
+
+func TestMain(m *testing.M) {
+	api.SetCommonCAPI(discardCAPI{})
+	os.Exit(m.Run())
+}
+
+func parse(t *testing.T, value map[string]interface{}) (*Configuration, error) {
+	t.Helper()
+	return parseWith(t, Parser{}, value)
+}
+
+func parseWith(t *testing.T, parser Parser, value map[string]interface{}) (*Configuration, error) {
+	t.Helper()
+	configStruct, err := structpb.NewStruct(value)
+	require.NoError(t, err)
+	configAny, err := anypb.New(&xds.TypedStruct{Value: configStruct})
+	require.NoError(t, err)
+	parsed, err := parser.Parse(configAny, nil)
+	if err != nil {
+		return nil, err
+	}
+	configuration := parsed.(*Configuration)
+	t.Cleanup(configuration.Destroy)
+	return configuration, nil
+}
+
+// problems returns the messages of the aggregated configuration errors by path.
+func problems(t *testing.T, err error) map[string]string {
+	t.Helper()
+	var aggregated configErrors
+	require.ErrorAs(t, err, &aggregated)
+	result := make(map[string]string, len(aggregated))
+	for _, err := range aggregated {
+		var pathErr *PathError
+		require.ErrorAs(t, err, &pathErr)
+		result[pathErr.Path] = pathErr.Err.Error()
+	}
+	return result
+}
+
+func directives(simpleDirectives ...interface{}) map[string]interface{} {
+	return map[string]interface{}{"simple_directives": simpleDirectives}
+}
+
+func TestParseValidConfiguration(t *testing.T) {
+	configuration, err := parse(t, map[string]interface{}{
+		"directives": map[string]interface{}{
+			"waf1": directives("SecRuleEngine On"),
+			"waf2": map[string]interface{}{"extends": "waf1"},
+		},
+		"default_directive":  "waf1",
+		"host_directive_map": map[string]interface{}{"foo.example.com": "waf2", "*.example.com": "waf1"},
+		"route_directive_map": []interface{}{
+			map[string]interface{}{"prefix": "/static/", "methods": []interface{}{"get"}, "directive": "waf2"},
+		},
+		"log_format": "json",
+	})
+	require.NoError(t, err)
+	require.NotNil(t, configuration.Waf("waf1"))
+	require.NotNil(t, configuration.Waf("waf2"))
+	require.Nil(t, configuration.Waf("waf3"))
+}
+
+func TestParseCompilesOnlyReferencedDirectiveSets(t *testing.T) {
+	configuration, err := parse(t, map[string]interface{}{
+		"directives": map[string]interface{}{
+			"base":      directives("SecRuleEngine On"),
+			"waf1":      map[string]interface{}{"extends": "base"},
+			"waf2":      map[string]interface{}{"extends": "waf1"},
+			"websocket": map[string]interface{}{"extends": "base"},
+			"unused":    directives("SecRuleEngine On"),
+		},
+		"default_directive":   "waf1",
+		"host_directive_map":  map[string]interface{}{"foo.example.com": "waf2"},
+		"websocket_directive": "websocket",
+	})
+	require.NoError(t, err)
+	// base is only extended, waf1 is extended and referenced
+	require.Nil(t, configuration.Waf("base"))
+	require.NotNil(t, configuration.Waf("waf1"))
+	require.NotNil(t, configuration.Waf("waf2"))
+	require.NotNil(t, configuration.Waf("websocket"))
+	// a set neither extended nor referenced can still be selected by a per route configuration
+	require.NotNil(t, configuration.Waf("unused"))
+
+	child, err := parse(t, map[string]interface{}{"default_directive": "base"})
+	require.NoError(t, err)
+	_, err = MergeConfigurations(configuration, child)
+	require.Contains(t, problems(t, err)["default_directive"], "is only used through extends")
+}
+
+func TestParseRejectsReloadIntervalsOfInheritingConfiguration(t *testing.T) {
+	_, err := parse(t, map[string]interface{}{
+		"default_directive":         "waf1",
+		"rules_reload_interval":     "10s",
+		"data_file_reload_interval": "10s",
+	})
+	found := problems(t, err)
+	require.Len(t, found, 2)
+	require.Contains(t, found["rules_reload_interval"], "is only valid with directives")
+	require.Contains(t, found["data_file_reload_interval"], "is only valid with directives")
+}
+
+func TestParseRejectsUnknownKeysAndWrongTypes(t *testing.T) {
+	_, err := parse(t, map[string]interface{}{
+		"directives": map[string]interface{}{
+			"waf1": map[string]interface{}{"simple_directives": []interface{}{"SecRuleEngine On", 1}, "extend": "waf2"},
+		},
+		"default_directive":         "waf1",
+		"host_directive_map":        map[string]interface{}{"foo.example.com": true},
+		"route_directive_map":       []interface{}{"/static/", map[string]interface{}{"prefix": "/api/", "methods": "GET", "directive": "waf1"}},
+		"use_re2":                   "yes",
+		"client_ip_hops":            1.5,
+		"trusted_proxies":           []interface{}{"10.0.0.0/8", 8},
+		"request_body_streaming":    4096,
+		"data_file_reload_interval": 10,
+	})
+	require.Equal(t, map[string]string{
+		`directives["waf1"].extend`:               "unknown key",
+		`directives["waf1"].simple_directives[1]`: "must be a string, got a number",
+		`host_directive_map["foo.example.com"]`:   "must be a string, got a boolean",
+		"route_directive_map[0]":                  "must be a map, got a string",
+		"route_directive_map[1].methods":          "must be a list, got a string",
+		"use_re2":                                 "must be a boolean, got a string",
+		"client_ip_hops":                          "must be an integer, got a number",
+		"trusted_proxies[1]":                      "must be a string, got a number",
+		"request_body_streaming":                  "unknown key",
+		"data_file_reload_interval":               "must be a string, got a number",
+	}, problems(t, err))
+}
+
+func TestParseReportsAllProblemsAtOnce(t *testing.T) {
+	_, err := parseWith(t, Parser{LocateBrokenDirectives: true}, map[string]interface{}{
+		"directives": map[string]interface{}{
+			"waf1": directives("SecRuleEngine On", "SecRule ARGS"),
+			"waf2": directives("SecRuleEngine On"),
+		},
+		"default_directive":  "waf1",
+		"host_directive_map": map[string]interface{}{"foo.example.com": "missing"},
+		"log_format":         "xml",
+		"unknown":            true,
+	})
+	found := problems(t, err)
+	require.Len(t, found, 4)
+	require.Equal(t, "unknown key", found["unknown"])
+	require.Contains(t, found["log_format"], "invalid value 'xml'")
+	require.Contains(t, found[`host_directive_map["foo.example.com"]`], "the referenced directive 'missing' does not exist")
+	require.Contains(t, found[`directives["waf1"].simple_directives[1]`], "waf init error")
+}
+
+func TestParseReportsBrokenInheritedDirectiveOnce(t *testing.T) {
+	_, err := parseWith(t, Parser{LocateBrokenDirectives: true}, map[string]interface{}{
+		"directives": map[string]interface{}{
+			"base":  directives("SecRuleEngine On", "SecRule ARGS"),
+			"waf1":  map[string]interface{}{"extends": "base", "simple_directives": []interface{}{"SecRequestBodyAccess On"}},
+			"cycle": map[string]interface{}{"extends": "cycle"},
+		},
+		"default_directive": "waf1",
+	})
+	found := problems(t, err)
+	require.Len(t, found, 2)
+	require.Contains(t, found[`directives["base"].simple_directives[1]`], "waf init error")
+	require.Contains(t, found[`directives["cycle"].extends`], "cyclic extends chain")
+}
+
+func TestParseReportsBrokenDirectiveSet(t *testing.T) {
+	value := map[string]interface{}{
+		"directives": map[string]interface{}{
+			"waf1": directives("SecRuleEngine On", "SecRule ARGS", "SecRequestBodyAccess On", "SecRule ARGS"),
+		},
+		"default_directive": "waf1",
+	}
+
+	// envoy does not compile the directive set again to locate the broken directive
+	_, err := parse(t, value)
+	found := problems(t, err)
+	require.Len(t, found, 1)
+	require.Contains(t, found[`directives["waf1"]`], "waf init error")
+
+	_, err = parseWith(t, Parser{LocateBrokenDirectives: true}, value)
+	found = problems(t, err)
+	require.Len(t, found, 1)
+	require.Contains(t, found[`directives["waf1"].simple_directives[1]`], "waf init error")
+}
+
+func TestParseRequiresDirectivesOfListenerConfiguration(t *testing.T) {
+	_, err := parse(t, map[string]interface{}{
+		"directives": map[string]interface{}{},
+	})
+	require.Equal(t, map[string]string{
+		"directives":        "must not be empty",
+		"default_directive": "is required",
+	}, problems(t, err))
+}
+
+func TestParseErrorIsAggregated(t *testing.T) {
+	_, err := parse(t, map[string]interface{}{"unknown": 1, "log_format": 1})
+	var aggregated configErrors
+	require.True(t, errors.As(err, &aggregated))
+	require.Contains(t, err.Error(), "invalid configuration, 2 problem(s) found")
+}
@@ -0,0 +1,96 @@
+// Copyright © 2026 United Security Providers AG, Switzerland
+// SPDX-License-Identifier: Apache-2.0
+
+package config
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+)
+
+// resolveExtends resolves the extends chains of all directive sets. The simple
+// directives of a parent are prepended to the ones of the child, recursively.
+// The block response of the parent is used if the child does not define one.
+func resolveExtends(wafDirectives WafDirectives) (WafDirectives, []error) {
+	resolved := make(WafDirectives, len(wafDirectives))
+	var resolve func(name string, chain []string) (Directives, error)
+	resolve = func(name string, chain []string) (Directives, error) {
+		if directives, ok := resolved[name]; ok {
+			return directives, nil
+		}
+		if slices.Contains(chain, name) {
+			return Directives{}, pathErrorf(fmt.Sprintf("directives[%q].extends", chain[0]), "cyclic extends chain %s -> %s", strings.Join(chain, " -> "), name)
+		}
+		directives := wafDirectives[name]
+		if directives.Extends != "" {
+			if _, ok := wafDirectives[directives.Extends]; !ok {
+				return Directives{}, pathErrorf(fmt.Sprintf("directives[%q].extends", name), "the extended directive '%s' does not exist", directives.Extends)
+			}
+			parent, err := resolve(directives.Extends, append(chain, name))
+			if err != nil {
+				return Directives{}, err
+			}
+			directives.SimpleDirectives = append(slices.Clip(parent.SimpleDirectives), directives.SimpleDirectives...)
+			if directives.BlockResponse == nil {
+				directives.BlockResponse = parent.BlockResponse
+			}
+		}
+		resolved[name] = directives
+		return directives, nil
+	}
+
+	var errs []error
+	for _, name := range slices.Sorted(maps.Keys(wafDirectives)) {
+		if _, err := resolve(name, nil); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return resolved, errs
+}
+
+// directiveError returns the error of the named directive set which failed to
+// compile. With locate set, the simple directive breaking the compilation is
+// searched: Coraza does not report the position of a broken directive, so
+// growing prefixes of the directive set are compiled one after the other until
+// the first one fails. This compiles the directive set up to once per simple
+// directive and is only done by offline checks, not while envoy loads a
+// configuration. The error points to the directive set defining the broken
+// directive, which is a parent for an inherited directive.
+func (c *Configuration) directiveError(name string, err error, locate bool) error {
+	simpleDirectives := c.directives[name].SimpleDirectives
+	broken := -1
+	for i := 0; locate && broken < 0 && i < len(simpleDirectives); i++ {
+		if compileDirectives(simpleDirectives[:i+1]) != nil {
+			broken = i
+		}
+	}
+	if broken < 0 {
+		return pathErrorf(fmt.Sprintf("directives[%q]", name), "waf init error: %w", err)
+	}
+	owner, index := c.directiveOrigin(name, broken)
+	return pathErrorf(fmt.Sprintf("directives[%q].simple_directives[%d]", owner, index), "waf init error: %w", err)
+}
+
+// directiveOrigin maps the index of a resolved simple directive to the
+// directive set defining it and its index in that set.
+func (c *Configuration) directiveOrigin(name string, index int) (string, int) {
+	for {
+		parent := c.directives[name].Extends
+		if parent == "" {
+			return name, index
+		}
+		inherited := len(c.directives[parent].SimpleDirectives)
+		if index >= inherited {
+			return name, index - inherited
+		}
+		name = parent
+	}
+}
+
+// compileDirectives compiles the directives without caching the WAF.
+func compileDirectives(simpleDirectives []string) error {
+	_, _, err := compileWaf(strings.Join(simpleDirectives, "\n"), 0)
+	return err
+}
@@ -0,0 +1,86 @@
+// Copyright © 2026 United Security Providers AG, Switzerland
+// SPDX-License-Identifier: Apache-2.0
+
+package config
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+)
+
+const wildcardHostPrefix = "*."
+
+// WildcardHostDirectiveMap maps wildcard host keys to directive names. The keys are
+// stored without the leading '*', e.g. "*.example.com:8443" is stored as ".example.com:8443".
+type WildcardHostDirectiveMap map[string]string
+
+// DirectiveForHost resolves the directive name for the given Host header.
+// The most specific entry wins:
+//  1. exact match on the Host header as received (e.g. "foo.example.com:8443")
+//  2. exact match on the hostname without port (e.g. "foo.example.com")
+//  3. wildcard match, the longest matching suffix wins. For the same suffix, an
+//     entry with a matching port (e.g. "*.example.com:8443") wins over one
+//     without a port (e.g. "*.example.com")
+//
+// If nothing matched, false is returned and the caller has to use the default directive.
+func (c *Configuration) DirectiveForHost(host string) (string, bool) {
+	if name, ok := c.HostDirectiveMap[host]; ok {
+		return name, true
+	}
+	hostname, port, err := net.SplitHostPort(host)
+	if err != nil {
+		hostname, port = host, ""
+	} else if name, ok := c.HostDirectiveMap[hostname]; ok {
+		return name, true
+	}
+	if len(c.WildcardHostDirectiveMap) == 0 {
+		return "", false
+	}
+	// walk the suffixes from the longest to the shortest one, a wildcard
+	// never matches the hostname itself, hence we start at the first dot
+	for suffix := hostname; ; suffix = suffix[1:] {
+		i := strings.IndexByte(suffix, '.')
+		if i == -1 {
+			break
+		}
+		suffix = suffix[i:]
+		if port != "" {
+			if name, ok := c.WildcardHostDirectiveMap[suffix+":"+port]; ok {
+				return name, true
+			}
+		}
+		if name, ok := c.WildcardHostDirectiveMap[suffix]; ok {
+			return name, true
+		}
+	}
+	return "", false
+}
+
+// parseHostKey validates a host_directive_map key. For wildcard keys the
+// returned key is stripped of the leading '*' so it can be used for suffix lookups.
+func parseHostKey(key string) (string, bool, error) {
+	if key == "" {
+		return "", false, errors.New("must not be empty")
+	}
+	if !strings.Contains(key, "*") {
+		return key, false, nil
+	}
+	if !strings.HasPrefix(key, wildcardHostPrefix) || strings.Count(key, "*") != 1 {
+		return "", false, fmt.Errorf("invalid wildcard host, only a leading '%s' is supported", wildcardHostPrefix)
+	}
+	hostname := key
+	if h, port, err := net.SplitHostPort(key); err == nil {
+		if _, err := strconv.ParseUint(port, 10, 16); err != nil {
+			return "", false, errors.New("invalid port in wildcard host")
+		}
+		hostname = h
+	}
+	suffix := hostname[len(wildcardHostPrefix):]
+	if suffix == "" || strings.HasPrefix(suffix, ".") || strings.HasSuffix(suffix, ".") || strings.Contains(suffix, "..") {
+		return "", false, errors.New("invalid wildcard host")
+	}
+	return key[1:], true, nil
+}
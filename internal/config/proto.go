@@ -0,0 +1,51 @@
+// Copyright © 2026 United Security Providers AG, Switzerland
+// SPDX-License-Identifier: Apache-2.0
+
+package config
+
+import (
+	"fmt"
+
+	wafv1 "coraza-waf/api/coraza/waf/v1"
+
+	xds "github.com/cncf/xds/go/xds/type/v3"
+	jsoniter "github.com/json-iterator/go"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/known/anypb"
+)
+
+// configMessageName is the name of the typed configuration message defined in
+// api/coraza/waf/v1/config.proto.
+const configMessageName protoreflect.FullName = "coraza.waf.v1.Config"
+
+// configValue returns the generic representation of the plugin configuration.
+// Both the TypedStruct and the typed configuration message are supported, the
+// typed message is checked against its protoc-gen-validate rules and converted
+// to the same representation as a TypedStruct value.
+func configValue(any *anypb.Any) (map[string]interface{}, error) {
+	if any.MessageName() == configMessageName {
+		message := &wafv1.Config{}
+		if err := any.UnmarshalTo(message); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal %s: %w", configMessageName, err)
+		}
+		if err := message.ValidateAll(); err != nil {
+			return nil, err
+		}
+		configJSON, err := protojson.MarshalOptions{UseProtoNames: true}.Marshal(message)
+		if err != nil {
+			return nil, fmt.Errorf("failed to convert %s: %w", configMessageName, err)
+		}
+		var v map[string]interface{}
+		if err := jsoniter.ConfigCompatibleWithStandardLibrary.Unmarshal(configJSON, &v); err != nil {
+			return nil, fmt.Errorf("failed to convert %s: %w", configMessageName, err)
+		}
+		return v, nil
+	}
+
+	configStruct := &xds.TypedStruct{}
+	if err := any.UnmarshalTo(configStruct); err != nil {
+		return nil, err
+	}
+	return configStruct.GetValue().AsMap(), nil
+}
@@ -0,0 +1,58 @@
+//  Copyright © 2026 United Security Providers AG, Switzerland
+//  SPDX-License-Identifier: Apache-2.0
+
+package config
+
+import (
+	"testing"
+
+	wafv1 "coraza-waf/api/coraza/waf/v1"
+
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/types/known/anypb"
+	"google.golang.org/protobuf/types/known/durationpb"
+)
+
+func TestConfigValueTypedMessage(t *testing.T) {
+	configAny, err := anypb.New(&wafv1.Config{
+		Directives: map[string]*wafv1.Directives{
+			"waf1": {SimpleDirectives: []string{"SecRuleEngine On"}},
+		},
+		DefaultDirective: "waf1",
+		RouteDirectiveMap: []*wafv1.RouteDirective{
+			{Directive: "waf1", PathSpecifier: &wafv1.RouteDirective_Prefix{Prefix: "/static/"}},
+		},
+		RulesReloadInterval: durationpb.New(5e9),
+	})
+	require.NoError(t, err)
+
+	v, err := configValue(configAny)
+	require.NoError(t, err)
+	require.Equal(t, map[string]interface{}{
+		"directives": map[string]interface{}{
+			"waf1": map[string]interface{}{"simple_directives": []interface{}{"SecRuleEngine On"}},
+		},
+		"default_directive": "waf1",
+		"route_directive_map": []interface{}{
+			map[string]interface{}{"directive": "waf1", "prefix": "/static/"},
+		},
+		"rules_reload_interval": "5s",
+	}, v)
+}
+
+func TestConfigValueTypedMessageValidation(t *testing.T) {
+	configAny, err := anypb.New(&wafv1.Config{
+		Directives: map[string]*wafv1.Directives{
+			"waf1": {BlockResponse: &wafv1.BlockResponse{Status: 100}},
+		},
+		LogFormat:         "xml",
+		RouteDirectiveMap: []*wafv1.RouteDirective{{Directive: "waf1"}},
+	})
+	require.NoError(t, err)
+
+	_, err = configValue(configAny)
+	require.ErrorContains(t, err, "invalid Config.Directives[waf1]")
+	require.ErrorContains(t, err, "invalid BlockResponse.Status: value must be inside range [200, 599]")
+	require.ErrorContains(t, err, "invalid RouteDirective.PathSpecifier: value is required")
+	require.ErrorContains(t, err, "invalid Config.LogFormat: value must be in list [ text json ftw]")
+}
@@ -0,0 +1,132 @@
+// Copyright © 2026 United Security Providers AG, Switzerland
+// SPDX-License-Identifier: Apache-2.0
+
+package config
+
+import (
+	"fmt"
+	htmltemplate "html/template"
+	"io"
+	"maps"
+	"slices"
+	"strings"
+	"text/template"
+
+	jsoniter "github.com/json-iterator/go"
+)
+
+// BlockResponse is the response sent to the client when a directive set interrupts a transaction.
+// The body and the header values are Go templates executed with BlockResponseData.
+type BlockResponse struct {
+	Status      int               `json:"status"`
+	ContentType string            `json:"content_type"`
+	Body        string            `json:"body"`
+	Headers     map[string]string `json:"headers"`
+
+	body    executor
+	headers map[string]*template.Template
+	// escape escapes the string values for a body which is not escaped by its template
+	escape func(string) string
+}
+
+// BlockResponseData is available in the templates of a block response, e.g. {{ .RequestID }}.
+type BlockResponseData struct {
+	RequestID     string
+	TransactionID string
+	RuleID        int
+	Phase         string
+	Status        int
+}
+
+// executor is implemented by text/template and html/template.
+type executor interface {
+	Execute(w io.Writer, data any) error
+}
+
+// compile parses the templates of the block response. A HTML body is parsed
+// with html/template, so the values are escaped for the context they are used in.
+// The values in a JSON body are escaped as the content of a JSON string.
+func (r *BlockResponse) compile(path string) []error {
+	var errs []error
+	if r.Status != 0 && (r.Status < 200 || r.Status > 599) {
+		errs = append(errs, pathErrorf(path+".status", "must be between 200 and 599, got %d", r.Status))
+	}
+	contentType := strings.ToLower(r.ContentType)
+	if strings.Contains(contentType, "json") {
+		r.escape = jsonStringContent
+	}
+	if strings.Contains(contentType, "html") {
+		body, err := htmltemplate.New("body").Parse(r.Body)
+		if err != nil {
+			errs = append(errs, pathErrorf(path+".body", "invalid template: %w", err))
+		}
+		r.body = body
+	} else {
+		body, err := template.New("body").Parse(r.Body)
+		if err != nil {
+			errs = append(errs, pathErrorf(path+".body", "invalid template: %w", err))
+		}
+		r.body = body
+	}
+	r.headers = make(map[string]*template.Template, len(r.Headers))
+	for _, name := range slices.Sorted(maps.Keys(r.Headers)) {
+		header, err := template.New(name).Parse(r.Headers[name])
+		if err != nil {
+			errs = append(errs, pathErrorf(fmt.Sprintf("%s.headers[%q]", path, name), "invalid template: %w", err))
+			continue
+		}
+		r.headers[name] = header
+	}
+	return errs
+}
+
+// Render returns the status, body and headers of the response for the interrupted transaction.
+// The status of the block response overrides the status of the interruption if it is set.
+func (r *BlockResponse) Render(data BlockResponseData) (int, string, map[string][]string, error) {
+	if r.Status != 0 {
+		data.Status = r.Status
+	}
+	bodyData := data
+	if r.escape != nil {
+		bodyData.RequestID = r.escape(data.RequestID)
+		bodyData.TransactionID = r.escape(data.TransactionID)
+		bodyData.Phase = r.escape(data.Phase)
+	}
+	var body strings.Builder
+	if err := r.body.Execute(&body, bodyData); err != nil {
+		return 0, "", nil, fmt.Errorf("failed to render block response body: %w", err)
+	}
+	// the request ID is supplied by the client, control characters would split or corrupt the header
+	headerData := data
+	headerData.RequestID = headerValueContent(data.RequestID)
+	headerData.TransactionID = headerValueContent(data.TransactionID)
+	headerData.Phase = headerValueContent(data.Phase)
+	headers := make(map[string][]string, len(r.headers)+1)
+	if r.ContentType != "" {
+		headers["content-type"] = []string{r.ContentType}
+	}
+	for name, header := range r.headers {
+		var value strings.Builder
+		if err := header.Execute(&value, headerData); err != nil {
+			return 0, "", nil, fmt.Errorf("failed to render block response header %s: %w", name, err)
+		}
+		headers[strings.ToLower(name)] = []string{value.String()}
+	}
+	return data.Status, body.String(), headers, nil
+}
+
+// headerValueContent removes the control characters, including CR and LF, from a value used in a header.
+func headerValueContent(value string) string {
+	return strings.Map(func(r rune) rune {
+		if r < 0x20 || r == 0x7f {
+			return -1
+		}
+		return r
+	}, value)
+}
+
+// jsonStringContent escapes the value to be used between the quotes of a JSON string.
+func jsonStringContent(value string) string {
+	quoted, _ := jsoniter.ConfigCompatibleWithStandardLibrary.Marshal(value)
+	return string(quoted[1 : len(quoted)-1])
+}
@@ -0,0 +1,85 @@
+//  Copyright © 2026 United Security Providers AG, Switzerland
+//  SPDX-License-Identifier: Apache-2.0
+
+package config
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// hostileRequestID is a client supplied x-request-id breaking out of its context.
+const hostileRequestID = `x", "status": 200, "<script>": "`
+
+func render(t *testing.T, blockResponse BlockResponse) (int, string, map[string][]string) {
+	t.Helper()
+	require.Empty(t, blockResponse.compile("block_response"))
+	status, body, headers, err := blockResponse.Render(BlockResponseData{
+		RequestID:     hostileRequestID,
+		TransactionID: "tx-1",
+		RuleID:        949110,
+		Phase:         "request_body",
+		Status:        403,
+	})
+	require.NoError(t, err)
+	return status, body, headers
+}
+
+func TestBlockResponseEscapesJSONBody(t *testing.T) {
+	for _, contentType := range []string{"application/json", "application/problem+json; charset=utf-8"} {
+		t.Run(contentType, func(t *testing.T) {
+			status, body, headers := render(t, BlockResponse{
+				ContentType: contentType,
+				Body:        `{"request_id": "{{ .RequestID }}", "rule": {{ .RuleID }}, "status": {{ .Status }}}`,
+				Headers:     map[string]string{"X-Request-Id": "{{ .RequestID }}"},
+			})
+			require.Equal(t, 403, status)
+			var decoded map[string]any
+			require.NoError(t, json.Unmarshal([]byte(body), &decoded))
+			require.Equal(t, map[string]any{"request_id": hostileRequestID, "rule": 949110.0, "status": 403.0}, decoded)
+			// headers are not part of the body and keep the raw value
+			require.Equal(t, []string{hostileRequestID}, headers["x-request-id"])
+		})
+	}
+}
+
+func TestBlockResponseRemovesControlCharactersFromHeaders(t *testing.T) {
+	blockResponse := BlockResponse{
+		ContentType: "text/plain",
+		Body:        "reference {{ .RequestID }}",
+		Headers:     map[string]string{"X-Request-Id": "{{ .RequestID }}", "X-Reference": "ref={{ .RequestID }}; tx={{ .TransactionID }}"},
+	}
+	require.Empty(t, blockResponse.compile("block_response"))
+	_, body, headers, err := blockResponse.Render(BlockResponseData{
+		RequestID:     "abc\r\nSet-Cookie: session=1\x00\x7f",
+		TransactionID: "tx-1",
+		Status:        403,
+	})
+	require.NoError(t, err)
+	require.Equal(t, []string{"abcSet-Cookie: session=1"}, headers["x-request-id"])
+	require.Equal(t, []string{"ref=abcSet-Cookie: session=1; tx=tx-1"}, headers["x-reference"])
+	// the body is not a header and keeps the value
+	require.Equal(t, "reference abc\r\nSet-Cookie: session=1\x00\x7f", body)
+}
+
+func TestBlockResponseEscapesHTMLBody(t *testing.T) {
+	_, body, _ := render(t, BlockResponse{
+		ContentType: "text/html",
+		Body:        "<p>Reference: {{ .RequestID }}</p>",
+	})
+	require.NotContains(t, body, "<script>")
+	require.Contains(t, body, "&lt;script&gt;")
+}
+
+func TestBlockResponseKeepsPlainTextBody(t *testing.T) {
+	status, body, headers := render(t, BlockResponse{
+		Status:      406,
+		ContentType: "text/plain",
+		Body:        "Blocked by {{ .RuleID }} in {{ .Phase }}, reference {{ .RequestID }}",
+	})
+	require.Equal(t, 406, status)
+	require.Equal(t, "Blocked by 949110 in request_body, reference "+hostileRequestID, body)
+	require.Equal(t, []string{"text/plain"}, headers["content-type"])
+}
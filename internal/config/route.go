@@ -0,0 +1,137 @@
+// Copyright © 2026 United Security Providers AG, Switzerland
+// SPDX-License-Identifier: Apache-2.0
+
+package config
+
+import (
+	"fmt"
+	"net/url"
+	"path"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// RouteDirectiveMap is an ordered list of route rules, the first matching rule wins.
+type RouteDirectiveMap []RouteDirective
+
+// RouteDirective selects a directive by path prefix or path regex and optionally by HTTP method.
+type RouteDirective struct {
+	Prefix    string
+	Regex     *regexp.Regexp
+	Methods   []string
+	Directive string
+}
+
+func (r RouteDirective) matches(method, path string) bool {
+	if len(r.Methods) > 0 && !slices.Contains(r.Methods, method) {
+		return false
+	}
+	if r.Regex != nil {
+		return r.Regex.MatchString(path)
+	}
+	return strings.HasPrefix(path, r.Prefix)
+}
+
+// DirectiveForRoute resolves the directive name for the given method and path
+// (including the query string, which is ignored for matching). The path is
+// matched normalized, see normalizeRoutePath.
+// If no rule matched, false is returned and the caller keeps the host directive.
+func (c *Configuration) DirectiveForRoute(method, requestPath string) (string, bool) {
+	if len(c.RouteDirectiveMap) == 0 {
+		return "", false
+	}
+	normalized, ok := normalizeRoutePath(requestPath)
+	if !ok {
+		// a path that can not be decoded must not select a more permissive WAF
+		return "", false
+	}
+	method = strings.ToUpper(method)
+	for _, r := range c.RouteDirectiveMap {
+		if r.matches(method, normalized) {
+			return r.Directive, true
+		}
+	}
+	return "", false
+}
+
+// normalizeRoutePath returns the path the upstream resolves the request to:
+// without query string and fragment, percent-decoded, with backslashes as
+// slashes and with dot segments and duplicate slashes removed. Otherwise
+// a path like /static/%2e%2e/admin would match a rule for /static/.
+// A trailing slash is kept. ok is false for an invalid percent-encoding.
+func normalizeRoutePath(requestPath string) (string, bool) {
+	if i := strings.IndexAny(requestPath, "?#"); i != -1 {
+		requestPath = requestPath[:i]
+	}
+	decoded, err := url.PathUnescape(requestPath)
+	if err != nil {
+		return "", false
+	}
+	// some upstreams treat a backslash as path separator
+	decoded = strings.ReplaceAll(decoded, "\\", "/")
+	normalized := path.Clean("/" + decoded)
+	if strings.HasSuffix(decoded, "/") && normalized != "/" {
+		normalized += "/"
+	}
+	return normalized, true
+}
+
+// parseRouteDirectiveMap parses the route rules, the structure was already validated
+// against the schema. Values of the wrong type were reported there and are skipped.
+func parseRouteDirectiveMap(routeDirectiveMapRaw []interface{}) (RouteDirectiveMap, []error) {
+	var errs []error
+	routeDirectiveMap := make(RouteDirectiveMap, 0, len(routeDirectiveMapRaw))
+	for i, routeRaw := range routeDirectiveMapRaw {
+		route, ok := routeRaw.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		path := fmt.Sprintf("route_directive_map[%d]", i)
+		var routeDirective RouteDirective
+
+		directive, ok := route["directive"].(string)
+		if !ok && route["directive"] == nil {
+			errs = append(errs, pathErrorf(path+".directive", "is required"))
+		}
+		routeDirective.Directive = directive
+
+		prefix, hasPrefix := route["prefix"].(string)
+		regex, hasRegex := route["regex"].(string)
+		switch {
+		case hasPrefix && hasRegex:
+			errs = append(errs, pathErrorf(path, "only one of prefix and regex can be set"))
+		case hasPrefix:
+			if prefix == "" {
+				errs = append(errs, pathErrorf(path+".prefix", "must not be empty"))
+			}
+			routeDirective.Prefix = prefix
+		case hasRegex:
+			compiled, err := regexp.Compile(regex)
+			if err != nil {
+				errs = append(errs, pathErrorf(path+".regex", "invalid regex: %w", err))
+			} else if regex == "" {
+				errs = append(errs, pathErrorf(path+".regex", "must not be empty"))
+			}
+			routeDirective.Regex = compiled
+		case route["prefix"] == nil && route["regex"] == nil:
+			errs = append(errs, pathErrorf(path, "either prefix or regex must be set"))
+		}
+
+		if methods, ok := route["methods"].([]interface{}); ok {
+			for j, methodRaw := range methods {
+				method, ok := methodRaw.(string)
+				if !ok {
+					continue
+				}
+				if method == "" {
+					errs = append(errs, pathErrorf(fmt.Sprintf("%s.methods[%d]", path, j), "must not be empty"))
+				}
+				routeDirective.Methods = append(routeDirective.Methods, strings.ToUpper(method))
+			}
+		}
+
+		routeDirectiveMap = append(routeDirectiveMap, routeDirective)
+	}
+	return routeDirectiveMap, errs
+}
@@ -0,0 +1,57 @@
+//  Copyright © 2026 United Security Providers AG, Switzerland
+//  SPDX-License-Identifier: Apache-2.0
+
+package config
+
+import (
+	"regexp"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestDirectiveForRouteNormalizesPath(t *testing.T) {
+	c := &Configuration{RouteDirectiveMap: RouteDirectiveMap{
+		{Prefix: "/static/", Methods: []string{"GET"}, Directive: "off"},
+		{Regex: regexp.MustCompile("^/upload(/|$)"), Directive: "upload"},
+	}}
+	tests := []struct {
+		name      string
+		path      string
+		directive string
+		matched   bool
+	}{
+		{"prefix", "/static/app.js", "off", true},
+		{"prefix with query", "/static/?arg=1", "off", true},
+		{"query is not matched", "/admin?/static/", "", false},
+		{"fragment is not matched", "/admin#/static/", "", false},
+		{"dot segments", "/static/../admin", "", false},
+		{"encoded dot segments", "/static/%2e%2e/admin", "", false},
+		{"encoded uppercase dot segments", "/static/%2E%2E/admin", "", false},
+		{"encoded slash", "/static/..%2fadmin", "", false},
+		{"backslashes", "/static\\..\\admin", "", false},
+		{"dot segment staying below the prefix", "/static/css/../app.js", "off", true},
+		{"current directory segment", "/./static/app.js", "off", true},
+		{"duplicate slashes", "//static//app.js", "off", true},
+		{"encoded prefix", "/%73tatic/app.js", "off", true},
+		{"invalid encoding", "/static/%zz", "", false},
+		{"regex", "/upload", "upload", true},
+		{"regex with dot segments", "/upload/../admin", "", false},
+		{"regex with trailing slash", "/upload/", "upload", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			directive, matched := c.DirectiveForRoute("get", tt.path)
+			require.Equal(t, tt.matched, matched)
+			require.Equal(t, tt.directive, directive)
+		})
+	}
+}
+
+func TestDirectiveForRouteMethod(t *testing.T) {
+	c := &Configuration{RouteDirectiveMap: RouteDirectiveMap{
+		{Prefix: "/static/", Methods: []string{"GET"}, Directive: "off"},
+	}}
+	_, matched := c.DirectiveForRoute("POST", "/static/app.js")
+	require.False(t, matched)
+}
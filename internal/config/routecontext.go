@@ -0,0 +1,81 @@
+// Copyright © 2026 United Security Providers AG, Switzerland
+// SPDX-License-Identifier: Apache-2.0
+
+package config
+
+import "sync"
+
+// RouteContext describes the Envoy configuration serving a request.
+type RouteContext struct {
+	Route       string
+	VirtualHost string
+	Cluster     string
+	FilterChain string
+}
+
+// LogArgs returns the non-empty names as key-value pairs for a logger.
+func (r RouteContext) LogArgs() []any {
+	var args []any
+	for _, field := range []struct{ key, value string }{
+		{"route", r.Route},
+		{"virtual-host", r.VirtualHost},
+		{"cluster", r.Cluster},
+		{"filter-chain", r.FilterChain},
+	} {
+		if field.value != "" {
+			args = append(args, field.key, field.value)
+		}
+	}
+	return args
+}
+
+// transactions holds the request ID and route context of the active transactions
+// by ID, errorCallback only gets the matched rule and looks them up. The filter
+// generates the ID of every transaction, so a client can not make requests share
+// an entry by sending the same x-request-id.
+var transactions = struct {
+	sync.Mutex
+	entries map[string]transactionContext
+}{entries: map[string]transactionContext{}}
+
+type transactionContext struct {
+	requestID string
+	route     RouteContext
+	// requestHeadersLogged is set if the matches of the request header rules were
+	// logged and transactions replaying them with the ID are not logged again
+	requestHeadersLogged bool
+}
+
+// RegisterTransaction makes the request ID and route context of a transaction
+// available to the rule logs until UnregisterTransaction is called with its ID.
+func RegisterTransaction(txID string, requestID string, route RouteContext) {
+	transactions.Lock()
+	defer transactions.Unlock()
+	transactions.entries[txID] = transactionContext{requestID: requestID, route: route}
+}
+
+// MarkRequestHeadersLogged stops logging the matches of the request header rules
+// of the transactions with the ID. The filter evaluates parts of a request in
+// transactions of their own, which evaluate the request header rules again before
+// their body rules, but the matches were already logged for the request.
+func MarkRequestHeadersLogged(txID string) {
+	transactions.Lock()
+	defer transactions.Unlock()
+	if transaction, ok := transactions.entries[txID]; ok {
+		transaction.requestHeadersLogged = true
+		transactions.entries[txID] = transaction
+	}
+}
+
+// UnregisterTransaction releases the context of a finished transaction.
+func UnregisterTransaction(txID string) {
+	transactions.Lock()
+	defer transactions.Unlock()
+	delete(transactions.entries, txID)
+}
+
+func lookupTransaction(txID string) transactionContext {
+	transactions.Lock()
+	defer transactions.Unlock()
+	return transactions.entries[txID]
+}
@@ -0,0 +1,26 @@
+//  Copyright © 2026 United Security Providers AG, Switzerland
+//  SPDX-License-Identifier: Apache-2.0
+
+package config
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestMarkRequestHeadersLogged(t *testing.T) {
+	RegisterTransaction("tx-1", "request-1", RouteContext{Route: "upload"})
+	t.Cleanup(func() { UnregisterTransaction("tx-1") })
+	require.False(t, lookupTransaction("tx-1").requestHeadersLogged)
+
+	MarkRequestHeadersLogged("tx-1")
+	transaction := lookupTransaction("tx-1")
+	require.True(t, transaction.requestHeadersLogged)
+	require.Equal(t, "request-1", transaction.requestID)
+	require.Equal(t, "upload", transaction.route.Route)
+
+	// an unknown transaction is not registered by marking it
+	MarkRequestHeadersLogged("tx-2")
+	require.Equal(t, transactionContext{}, lookupTransaction("tx-2"))
+}
@@ -0,0 +1,218 @@
+// Copyright © 2026 United Security Providers AG, Switzerland
+// SPDX-License-Identifier: Apache-2.0
+
+package config
+
+import (
+	"fmt"
+	"maps"
+	"math"
+	"slices"
+	"strings"
+)
+
+type schemaKind int
+
+const (
+	kindString schemaKind = iota
+	kindBool
+	kindInteger
+	kindList
+	kindObject
+	kindMap
+)
+
+var schemaKindName = map[schemaKind]string{
+	kindString:  "a string",
+	kindBool:    "a boolean",
+	kindInteger: "an integer",
+	kindList:    "a list",
+	kindObject:  "a map",
+	kindMap:     "a map",
+}
+
+// schema describes the expected structure of a configuration value.
+// Objects have a fixed set of known fields, maps have arbitrary keys with values of the same schema.
+type schema struct {
+	kind   schemaKind
+	fields map[string]*schema
+	values *schema
+	items  *schema
+}
+
+var (
+	stringSchema     = &schema{kind: kindString}
+	boolSchema       = &schema{kind: kindBool}
+	integerSchema    = &schema{kind: kindInteger}
+	stringListSchema = &schema{kind: kindList, items: stringSchema}
+	stringMapSchema  = &schema{kind: kindMap, values: stringSchema}
+)
+
+// configSchema is the schema of the plugin configuration. Every key not listed here is rejected.
+var configSchema = &schema{kind: kindObject, fields: map[string]*schema{
+	"directives": {kind: kindMap, values: &schema{kind: kindObject, fields: map[string]*schema{
+		"simple_directives": stringListSchema,
+		"extends":           stringSchema,
+		"block_response": {kind: kindObject, fields: map[string]*schema{
+			"status":       integerSchema,
+			"content_type": stringSchema,
+			"body":         stringSchema,
+			"headers":      stringMapSchema,
+		}},
+	}}},
+	"default_directive":  stringSchema,
+	"host_directive_map": stringMapSchema,
+	"route_directive_map": {kind: kindList, items: &schema{kind: kindObject, fields: map[string]*schema{
+		"directive": stringSchema,
+		"prefix":    stringSchema,
+		"regex":     stringSchema,
+		"methods":   stringListSchema,
+	}}},
+	"log_format":                      stringSchema,
+	"use_re2":                         boolSchema,
+	"use_libinjection":                boolSchema,
+	"rules_reload_interval":           stringSchema,
+	"data_file_reload_interval":       stringSchema,
+	"grpc_descriptor_set":             stringSchema,
+	"websocket_directive":             stringSchema,
+	"sse_event_inspection":            boolSchema,
+	"request_body_streaming_interval": integerSchema,
+	"response_decompression": {kind: kindObject, fields: map[string]*schema{
+		"max_size":  integerSchema,
+		"max_ratio": integerSchema,
+	}},
+	"request_decompression": {kind: kindObject, fields: map[string]*schema{
+		"max_ratio":            integerSchema,
+		"unsupported_encoding": stringSchema,
+	}},
+	"trusted_proxies":             stringListSchema,
+	"client_ip_header":            stringSchema,
+	"client_ip_hops":              integerSchema,
+	"client_address_filter_state": stringSchema,
+}}
+
+// validate checks the value against the schema and returns an error for every
+// problem found. A nil value is treated as not set and is always valid.
+func (s *schema) validate(path string, value interface{}) []error {
+	if value == nil {
+		return nil
+	}
+	var errs []error
+	switch s.kind {
+	case kindString:
+		if _, ok := value.(string); !ok {
+			errs = append(errs, s.typeError(path, value))
+		}
+	case kindBool:
+		if _, ok := value.(bool); !ok {
+			errs = append(errs, s.typeError(path, value))
+		}
+	case kindInteger:
+		if number, ok := value.(float64); !ok || number != math.Trunc(number) {
+			errs = append(errs, s.typeError(path, value))
+		}
+	case kindList:
+		items, ok := value.([]interface{})
+		if !ok {
+			return append(errs, s.typeError(path, value))
+		}
+		for i, item := range items {
+			if item == nil {
+				errs = append(errs, pathErrorf(fmt.Sprintf("%s[%d]", path, i), "must not be empty"))
+				continue
+			}
+			errs = append(errs, s.items.validate(fmt.Sprintf("%s[%d]", path, i), item)...)
+		}
+	case kindObject:
+		fields, ok := value.(map[string]interface{})
+		if !ok {
+			return append(errs, s.typeError(path, value))
+		}
+		for _, key := range slices.Sorted(maps.Keys(fields)) {
+			fieldSchema, ok := s.fields[key]
+			if !ok {
+				errs = append(errs, pathErrorf(joinPath(path, key), "unknown key"))
+				continue
+			}
+			errs = append(errs, fieldSchema.validate(joinPath(path, key), fields[key])...)
+		}
+	case kindMap:
+		values, ok := value.(map[string]interface{})
+		if !ok {
+			return append(errs, s.typeError(path, value))
+		}
+		for _, key := range slices.Sorted(maps.Keys(values)) {
+			keyPath := fmt.Sprintf("%s[%q]", path, key)
+			if values[key] == nil {
+				errs = append(errs, pathErrorf(keyPath, "must not be empty"))
+				continue
+			}
+			errs = append(errs, s.values.validate(keyPath, values[key])...)
+		}
+	}
+	return errs
+}
+
+func (s *schema) typeError(path string, value interface{}) error {
+	return pathErrorf(path, "must be %s, got %s", schemaKindName[s.kind], valueTypeName(value))
+}
+
+func valueTypeName(value interface{}) string {
+	switch value.(type) {
+	case string:
+		return "a string"
+	case bool:
+		return "a boolean"
+	case float64:
+		return "a number"
+	case []interface{}:
+		return "a list"
+	case map[string]interface{}:
+		return "a map"
+	default:
+		return fmt.Sprintf("%T", value)
+	}
+}
+
+func joinPath(path string, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
+
+// PathError is a problem with the configuration value at Path, for example
+// directives["waf1"].simple_directives[2]. The path allows tools to point to
+// the offending line of a configuration file.
+type PathError struct {
+	Path string
+	Err  error
+}
+
+func (e *PathError) Error() string {
+	return e.Path + ": " + e.Err.Error()
+}
+
+func (e *PathError) Unwrap() error {
+	return e.Err
+}
+
+func pathErrorf(path string, format string, args ...any) error {
+	return &PathError{Path: path, Err: fmt.Errorf(format, args...)}
+}
+
+// configErrors aggregates all problems of a configuration into a single error,
+// so an operator can fix all of them with a single configuration push.
+type configErrors []error
+
+func (e configErrors) Error() string {
+	messages := make([]string, 0, len(e))
+	for _, err := range e {
+		messages = append(messages, err.Error())
+	}
+	return fmt.Sprintf("invalid configuration, %d problem(s) found: %s", len(e), strings.Join(messages, "; "))
+}
+
+func (e configErrors) Unwrap() []error {
+	return e
+}
@@ -0,0 +1,230 @@
+// Copyright © 2026 United Security Providers AG, Switzerland
+// SPDX-License-Identifier: Apache-2.0
+
+// Package datafile replaces the @ipMatchFromFile and @pmFromFile operators with
+// implementations which reload their data files when they change, without
+// recompiling the WAF.
+package datafile
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/corazawaf/coraza/v3/experimental/plugins"
+
+	"coraza-waf/internal/logging"
+)
+
+var (
+	registerOnce sync.Once
+
+	mu sync.Mutex
+	// files holds the watched data files. Rules using the same file at the
+	// same interval share the parsed data, so it is parsed and reloaded only once.
+	files = make(map[fileID]*watchedFile)
+)
+
+// fileID identifies a watched data file by operator kind, path and reload interval.
+type fileID struct {
+	kind     string
+	path     string
+	interval time.Duration
+}
+
+type reloader interface {
+	reload()
+}
+
+// watchedFile is a data file polled for changes as long as a scope uses it.
+type watchedFile struct {
+	file reloader
+	refs int
+	stop chan struct{}
+}
+
+// Register replaces the file based operators. The data files of a WAF compiled
+// with a Scope as root filesystem are reloaded at the interval of the scope, the
+// data files of other WAFs are read once when the WAF is compiled, like coraza's
+// operators do. Coraza keeps a single operator registry per process and the stock
+// operators can not be restored, so the operators stay registered for the lifetime
+// of the process and apply to the WAFs of every configuration.
+func Register() {
+	registerOnce.Do(func() {
+		plugins.RegisterOperator("ipMatchFromFile", newIPMatchFromFile)
+		plugins.RegisterOperator("ipMatchF", newIPMatchFromFile)
+		plugins.RegisterOperator("pmFromFile", newPMFromFile)
+		plugins.RegisterOperator("pmf", newPMFromFile)
+	})
+}
+
+// Scope is the root filesystem of a WAF which reloads its data files. The
+// operators of the WAF register the data files they load with the scope, the
+// files are watched until the scope is released together with the WAF.
+type Scope struct {
+	root     fs.FS
+	interval time.Duration
+	// files holds a reference for every operator of the WAF, guarded by mu
+	files []fileID
+}
+
+var _ fs.ReadFileFS = (*Scope)(nil)
+var _ fs.ReadDirFS = (*Scope)(nil)
+
+// NewScope returns a scope reading from root and reloading data files at the given interval.
+func NewScope(root fs.FS, interval time.Duration) *Scope {
+	return &Scope{root: root, interval: interval}
+}
+
+func (s *Scope) Open(name string) (fs.File, error) {
+	return s.root.Open(name)
+}
+
+func (s *Scope) ReadDir(name string) ([]fs.DirEntry, error) {
+	return fs.ReadDir(s.root, name)
+}
+
+func (s *Scope) ReadFile(name string) ([]byte, error) {
+	return fs.ReadFile(s.root, name)
+}
+
+// Release stops watching the data files of the scope which are not used by other scopes.
+func (s *Scope) Release() {
+	if s == nil {
+		return
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	for _, id := range s.files {
+		watched := files[id]
+		watched.refs--
+		if watched.refs == 0 {
+			close(watched.stop)
+			delete(files, id)
+		}
+	}
+	s.files = nil
+}
+
+// watch polls the data file until it is not used anymore.
+func watch(file reloader, interval time.Duration, stop <-chan struct{}) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+			file.reload()
+		}
+	}
+}
+
+// dataFile is a data file parsed into T. The parsed data is replaced atomically
+// when the file changes, so a rule evaluation always sees a consistent data set.
+type dataFile[T any] struct {
+	root  fs.FS
+	path  string
+	parse func(entries []string) *T
+	data  atomic.Pointer[T]
+	hash  [sha256.Size]byte
+}
+
+// load returns the data file of the given kind, loading and parsing it. If root
+// is a Scope, the file is shared with the other operators watching it at the
+// same interval and is watched until the scope is released. Relative paths are
+// resolved like coraza does, against the directories of the rule files.
+func load[T any](kind string, name string, dirs []string, root fs.FS, parse func(entries []string) *T) (*dataFile[T], error) {
+	filePath, content, err := readFile(name, dirs, root)
+	if err != nil {
+		return nil, err
+	}
+
+	scope, ok := root.(*Scope)
+	// embedded files can not change and are not watched
+	if !ok || strings.HasPrefix(filePath, "@") {
+		file := &dataFile[T]{root: root, path: filePath, parse: parse, hash: sha256.Sum256(content)}
+		file.data.Store(parse(entries(content)))
+		return file, nil
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	id := fileID{kind: kind, path: filePath, interval: scope.interval}
+	watched, ok := files[id]
+	if !ok {
+		file := &dataFile[T]{root: scope.root, path: filePath, parse: parse, hash: sha256.Sum256(content)}
+		file.data.Store(parse(entries(content)))
+		watched = &watchedFile{file: file, stop: make(chan struct{})}
+		files[id] = watched
+		go watch(file, scope.interval, watched.stop)
+	}
+	watched.refs++
+	scope.files = append(scope.files, id)
+	return watched.file.(*dataFile[T]), nil
+}
+
+// reload parses the file again if its content changed. If the file can not be
+// read the previous data is kept.
+func (f *dataFile[T]) reload() {
+	logger := logging.GetLogger().With("phase", "data-file-reload", "file", f.path)
+	content, err := fs.ReadFile(f.root, f.path)
+	if err != nil {
+		logger.Error("failed to read data file, keeping the previous data", "error", err.Error())
+		return
+	}
+	hash := sha256.Sum256(content)
+	if hash == f.hash {
+		return
+	}
+	f.hash = hash
+	entries := entries(content)
+	f.data.Store(f.parse(entries))
+	logger.Info("reloaded data file", "entries", len(entries))
+}
+
+// readFile reads an absolute path directly and a relative path from the first directory containing it.
+func readFile(name string, dirs []string, root fs.FS) (string, []byte, error) {
+	if path.IsAbs(name) {
+		content, err := fs.ReadFile(root, name)
+		return name, content, err
+	}
+	if len(dirs) == 0 {
+		return "", nil, fmt.Errorf("data file %s: relative path without a rule file directory", name)
+	}
+	var err error
+	for _, dir := range dirs {
+		filePath := path.Join(dir, name)
+		var content []byte
+		content, err = fs.ReadFile(root, filePath)
+		if err == nil {
+			return filePath, content, nil
+		}
+		if !errors.Is(err, fs.ErrNotExist) {
+			return "", nil, err
+		}
+	}
+	return "", nil, err
+}
+
+// entries returns the non empty lines of a data file, lines starting with '#' are comments.
+func entries(content []byte) []string {
+	var lines []string
+	scanner := bufio.NewScanner(bytes.NewReader(content))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || line[0] == '#' {
+			continue
+		}
+		lines = append(lines, line)
+	}
+	return lines
+}
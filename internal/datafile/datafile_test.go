@@ -0,0 +1,85 @@
+//  Copyright © 2026 United Security Providers AG, Switzerland
+//  SPDX-License-Identifier: Apache-2.0
+
+package datafile
+
+import (
+	"os"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/envoyproxy/envoy/contrib/golang/common/go/api"
+	"github.com/stretchr/testify/require"
+)
+
+// discardCAPI replaces the envoy logging API, which is not available outside of envoy.
+type discardCAPI struct{}
+
+func (discardCAPI) Log(api.LogType, string) {}
+
+func (discardCAPI) LogLevel() api.LogType { return api.Critical }
+
+func TestMain(m *testing.M) {
+	api.SetCommonCAPI(discardCAPI{})
+	os.Exit(m.Run())
+}
+
+func watched(id fileID) bool {
+	mu.Lock()
+	defer mu.Unlock()
+	_, ok := files[id]
+	return ok
+}
+
+func TestScopeSharesAndReleasesWatchedFiles(t *testing.T) {
+	root := fstest.MapFS{"data/ips.txt": {Data: []byte("10.0.0.0/8\n")}}
+	first := NewScope(root, time.Hour)
+	second := NewScope(root, time.Hour)
+	id := fileID{kind: "ipMatch", path: "data/ips.txt", interval: time.Hour}
+
+	firstFile, err := load("ipMatch", "ips.txt", []string{"data"}, first, parseIPSet)
+	require.NoError(t, err)
+	secondFile, err := load("ipMatch", "ips.txt", []string{"data"}, second, parseIPSet)
+	require.NoError(t, err)
+	require.Same(t, firstFile, secondFile)
+
+	// another interval does not share the file
+	other := NewScope(root, time.Minute)
+	otherFile, err := load("ipMatch", "ips.txt", []string{"data"}, other, parseIPSet)
+	require.NoError(t, err)
+	require.NotSame(t, firstFile, otherFile)
+	other.Release()
+
+	first.Release()
+	require.True(t, watched(id))
+	second.Release()
+	require.False(t, watched(id))
+}
+
+func TestLoadWithoutScopeIsNotWatched(t *testing.T) {
+	root := fstest.MapFS{"data/phrases.txt": {Data: []byte("foo\nbar\n")}}
+	_, err := load("pm", "phrases.txt", []string{"data"}, root, parsePhraseList)
+	require.NoError(t, err)
+	require.False(t, watched(fileID{kind: "pm", path: "data/phrases.txt"}))
+}
+
+func TestReloadReplacesChangedData(t *testing.T) {
+	root := fstest.MapFS{"data/ips.txt": {Data: []byte("10.0.0.0/8\n")}}
+	scope := NewScope(root, time.Hour)
+	t.Cleanup(scope.Release)
+	file, err := load("ipMatch", "ips.txt", []string{"data"}, scope, parseIPSet)
+	require.NoError(t, err)
+	operator := &ipMatchFromFile{file: file}
+	require.True(t, operator.Evaluate(nil, "10.1.2.3"))
+
+	root["data/ips.txt"] = &fstest.MapFile{Data: []byte("192.168.0.0/16\n")}
+	file.reload()
+	require.False(t, operator.Evaluate(nil, "10.1.2.3"))
+	require.True(t, operator.Evaluate(nil, "192.168.1.1"))
+
+	// the previous data is kept if the file can not be read
+	delete(root, "data/ips.txt")
+	file.reload()
+	require.True(t, operator.Evaluate(nil, "192.168.1.1"))
+}
@@ -0,0 +1,114 @@
+// Copyright © 2026 United Security Providers AG, Switzerland
+// SPDX-License-Identifier: Apache-2.0
+
+package datafile
+
+import (
+	"net/netip"
+	"strings"
+
+	"github.com/corazawaf/coraza/v3/experimental/plugins/plugintypes"
+	ahocorasick "github.com/petar-dambovaliev/aho-corasick"
+)
+
+// maxCaptures is the number of matched phrases captured into TX.0 - TX.9, like coraza's @pm does.
+const maxCaptures = 10
+
+type ipSet struct {
+	prefixes []netip.Prefix
+}
+
+type ipMatchFromFile struct {
+	file *dataFile[ipSet]
+}
+
+var _ plugintypes.Operator = (*ipMatchFromFile)(nil)
+
+func newIPMatchFromFile(options plugintypes.OperatorOptions) (plugintypes.Operator, error) {
+	file, err := load("ipMatch", options.Arguments, options.Path, options.Root, parseIPSet)
+	if err != nil {
+		return nil, err
+	}
+	return &ipMatchFromFile{file: file}, nil
+}
+
+func (o *ipMatchFromFile) Evaluate(_ plugintypes.TransactionState, value string) bool {
+	ip, err := netip.ParseAddr(value)
+	if err != nil {
+		return false
+	}
+	ip = ip.Unmap()
+	for _, prefix := range o.file.data.Load().prefixes {
+		if prefix.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// parseIPSet parses IP addresses and CIDR ranges, invalid entries are skipped like coraza's @ipMatch does.
+func parseIPSet(entries []string) *ipSet {
+	set := &ipSet{}
+	for _, entry := range entries {
+		if !strings.Contains(entry, "/") {
+			ip, err := netip.ParseAddr(entry)
+			if err != nil {
+				continue
+			}
+			set.prefixes = append(set.prefixes, netip.PrefixFrom(ip.Unmap(), ip.Unmap().BitLen()))
+			continue
+		}
+		prefix, err := netip.ParsePrefix(entry)
+		if err != nil {
+			continue
+		}
+		set.prefixes = append(set.prefixes, prefix.Masked())
+	}
+	return set
+}
+
+type phraseList struct {
+	matcher ahocorasick.AhoCorasick
+}
+
+type pmFromFile struct {
+	file *dataFile[phraseList]
+}
+
+var _ plugintypes.Operator = (*pmFromFile)(nil)
+
+func newPMFromFile(options plugintypes.OperatorOptions) (plugintypes.Operator, error) {
+	file, err := load("pm", options.Arguments, options.Path, options.Root, parsePhraseList)
+	if err != nil {
+		return nil, err
+	}
+	return &pmFromFile{file: file}, nil
+}
+
+func (o *pmFromFile) Evaluate(tx plugintypes.TransactionState, value string) bool {
+	matcher := o.file.data.Load().matcher
+	if tx == nil || !tx.Capturing() {
+		// without capturing a single match is enough
+		return matcher.Iter(value).Next() != nil
+	}
+	matches := 0
+	for _, match := range matcher.FindAll(value) {
+		tx.CaptureField(matches, value[match.Start():match.End()])
+		matches++
+		if matches == maxCaptures {
+			break
+		}
+	}
+	return matches > 0
+}
+
+// parsePhraseList builds a case insensitive matcher for the phrases, like coraza's @pm does.
+func parsePhraseList(entries []string) *phraseList {
+	builder := ahocorasick.NewAhoCorasickBuilder(ahocorasick.Opts{
+		AsciiCaseInsensitive: true,
+		MatchOnlyWholeWords:  false,
+		MatchKind:            ahocorasick.LeftMostLongestMatch,
+		DFA:                  true,
+	})
+	return &phraseList{matcher: builder.Build(entries)}
+}
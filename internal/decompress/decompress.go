@@ -0,0 +1,170 @@
+// Copyright © 2026 United Security Providers AG, Switzerland
+// SPDX-License-Identifier: Apache-2.0
+
+// Package decompress decompresses HTTP bodies according to their Content-Encoding,
+// so the rules can inspect the decompressed body.
+package decompress
+
+import (
+	"bufio"
+	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"compress/zlib"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/andybalholm/brotli"
+	"github.com/klauspost/compress/zstd"
+)
+
+// DefaultMaxSize is the default maximum size of a decompressed body.
+const DefaultMaxSize = 4 << 20
+
+// DefaultMaxRatio is the default maximum ratio between the decompressed and the compressed size of a body.
+const DefaultMaxRatio = 100
+
+// zstdMaxMemory limits the window of a zstd frame, the window is allocated up front.
+const zstdMaxMemory = 64 << 20
+
+var (
+	ErrUnsupportedEncoding = errors.New("unsupported content encoding")
+	ErrLimitExceeded       = errors.New("decompressed body exceeds the limits")
+)
+
+// Limits protect against decompression bombs.
+type Limits struct {
+	// MaxSize is the maximum size of the decompressed body
+	MaxSize int
+	// MaxRatio is the maximum ratio between the decompressed and the compressed size
+	MaxRatio int
+}
+
+// Supported reports whether all encodings of a Content-Encoding header value can be decompressed.
+func Supported(contentEncoding string) bool {
+	for _, encoding := range encodings(contentEncoding) {
+		switch encoding {
+		case "gzip", "x-gzip", "deflate", "br", "zstd":
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// Identity reports whether a Content-Encoding header value does not compress the body.
+func Identity(contentEncoding string) bool {
+	return len(encodings(contentEncoding)) == 0
+}
+
+// encodings returns the encodings of a Content-Encoding header value in the order they were applied, without identity.
+func encodings(contentEncoding string) []string {
+	var result []string
+	for _, encoding := range strings.Split(contentEncoding, ",") {
+		encoding = strings.ToLower(strings.TrimSpace(encoding))
+		if encoding != "" && encoding != "identity" {
+			result = append(result, encoding)
+		}
+	}
+	return result
+}
+
+// Reader decompresses a body. It fails with ErrLimitExceeded once the
+// decompressed body exceeds the limits.
+type Reader struct {
+	decoder io.Reader
+	closers []io.Closer
+	limit   int
+	read    int
+}
+
+// NewReader returns a Reader decompressing body according to contentEncoding,
+// the value of a Content-Encoding header. Multiple encodings are removed in
+// reverse order.
+func NewReader(body []byte, contentEncoding string, limits Limits) (*Reader, error) {
+	r := &Reader{
+		decoder: bytes.NewReader(body),
+		limit:   min(limits.MaxSize, len(body)*limits.MaxRatio),
+	}
+	list := encodings(contentEncoding)
+	for i := len(list) - 1; i >= 0; i-- {
+		if err := r.push(list[i]); err != nil {
+			_ = r.Close()
+			return nil, err
+		}
+	}
+	return r, nil
+}
+
+func (r *Reader) push(encoding string) error {
+	switch encoding {
+	case "gzip", "x-gzip":
+		decoder, err := gzip.NewReader(r.decoder)
+		if err != nil {
+			return fmt.Errorf("invalid gzip body: %w", err)
+		}
+		r.decoder = decoder
+		r.closers = append(r.closers, decoder)
+	case "deflate":
+		// deflate is specified as zlib, but some servers send raw deflate
+		buffered := bufio.NewReader(r.decoder)
+		header, err := buffered.Peek(2)
+		if err != nil {
+			return fmt.Errorf("invalid deflate body: %w", err)
+		}
+		if header[0]&0x0f == 8 && (uint16(header[0])<<8|uint16(header[1]))%31 == 0 {
+			decoder, err := zlib.NewReader(buffered)
+			if err != nil {
+				return fmt.Errorf("invalid deflate body: %w", err)
+			}
+			r.decoder = decoder
+			r.closers = append(r.closers, decoder)
+		} else {
+			decoder := flate.NewReader(buffered)
+			r.decoder = decoder
+			r.closers = append(r.closers, decoder)
+		}
+	case "br":
+		// brotli has no header to validate, an invalid body fails on the first read
+		r.decoder = brotli.NewReader(r.decoder)
+	case "zstd":
+		decoder, err := zstd.NewReader(r.decoder, zstd.WithDecoderConcurrency(1), zstd.WithDecoderMaxMemory(zstdMaxMemory))
+		if err != nil {
+			return fmt.Errorf("invalid zstd body: %w", err)
+		}
+		r.decoder = decoder
+		r.closers = append(r.closers, decoder.IOReadCloser())
+	default:
+		return fmt.Errorf("%w '%s'", ErrUnsupportedEncoding, encoding)
+	}
+	return nil
+}
+
+// Read reads decompressed data. Data beyond the limits is not returned, instead
+// the read fails with ErrLimitExceeded.
+func (r *Reader) Read(p []byte) (int, error) {
+	if r.read >= r.limit {
+		var probe [1]byte
+		if n, err := io.ReadFull(r.decoder, probe[:]); n == 0 {
+			return 0, err
+		}
+		return 0, ErrLimitExceeded
+	}
+	if len(p) > r.limit-r.read {
+		p = p[:r.limit-r.read]
+	}
+	n, err := r.decoder.Read(p)
+	r.read += n
+	return n, err
+}
+
+// Close releases the decoders.
+func (r *Reader) Close() error {
+	var errs []error
+	for i := len(r.closers) - 1; i >= 0; i-- {
+		errs = append(errs, r.closers[i].Close())
+	}
+	return errors.Join(errs...)
+}
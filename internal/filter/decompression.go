@@ -0,0 +1,209 @@
+// Copyright © 2026 United Security Providers AG, Switzerland
+// SPDX-License-Identifier: Apache-2.0
+
+package filter
+
+import (
+	"errors"
+	"io"
+	"math"
+	"net/http"
+
+	"github.com/envoyproxy/envoy/contrib/golang/common/go/api"
+
+	"coraza-waf/internal/config"
+	"coraza-waf/internal/decompress"
+	"coraza-waf/internal/logging"
+)
+
+// decompressionChunkSize is the size of the decompressed chunks written to the transaction.
+const decompressionChunkSize = 32 << 10
+
+// compressedBody collects a compressed body, it is decompressed for the inspection once it is complete.
+type compressedBody struct {
+	encoding string
+	limits   decompress.Limits
+	data     []byte
+}
+
+// writeCompressedResponseData collects a compressed response body and writes
+// the decompressed body to the transaction at the end of the response. The
+// compressed data is passed downstream unchanged.
+func (f *Filter) writeCompressedResponseData(logger logging.Logger, buffer api.BufferInstance, endStream bool) api.StatusType {
+	body := f.compressedResponse
+	logger.Debug("Collecting compressed response data", "size", buffer.Len(), "encoding", body.encoding)
+	// a body larger than the maximum size can not decompress to less, the rest is not needed
+	if len(body.data) < body.limits.MaxSize {
+		body.data = append(body.data, buffer.Bytes()...)
+	}
+	if !endStream {
+		return api.Continue
+	}
+	return f.writeCompressedResponse(logger)
+}
+
+// writeCompressedResponse writes the decompressed response body to the transaction,
+// once the response ended with the last data or with trailers.
+func (f *Filter) writeCompressedResponse(logger logging.Logger) api.StatusType {
+	body := f.compressedResponse
+	defer func() { body.data = nil }()
+	reader, err := decompress.NewReader(body.data, body.encoding, body.limits)
+	if err != nil {
+		logger.Warn("Failed to decompress response body, inspecting the compressed body", "encoding", body.encoding, "error", err.Error())
+		status, _ := f.writeResponseChunk(logger, body.data)
+		return status
+	}
+	defer reader.Close()
+	chunk := make([]byte, decompressionChunkSize)
+	for {
+		n, err := reader.Read(chunk)
+		if n > 0 {
+			status, written := f.writeResponseChunk(logger, chunk[:n])
+			if status != api.Continue {
+				return status
+			}
+			if !written {
+				logger.Debug("Response body limit reached, stopping the decompression")
+				return api.Continue
+			}
+		}
+		switch {
+		case err == io.EOF:
+			return api.Continue
+		case errors.Is(err, decompress.ErrLimitExceeded):
+			logger.Warn("Decompressed response body exceeds the limits, inspecting the body up to the limits", "encoding", body.encoding)
+			return api.Continue
+		case err != nil:
+			logger.Warn("Failed to decompress response body, inspecting the body decompressed so far", "encoding", body.encoding, "error", err.Error())
+			return api.Continue
+		}
+	}
+}
+
+// writeResponseChunk writes a chunk of the body to the transaction. written is
+// false if the transaction did not take all of it because the body limit was reached.
+func (f *Filter) writeResponseChunk(logger logging.Logger, chunk []byte) (api.StatusType, bool) {
+	interruption, buffered, err := f.tx.WriteResponseBody(chunk)
+	logger.Debug("Buffered response body data", "size", buffered)
+	if err != nil {
+		logger.Error("Failed to write response body", "error", err)
+		f.Callbacks.EncoderFilterCallbacks().SendLocalReply(http.StatusInternalServerError, "", map[string][]string{}, 0, "")
+		return api.LocalReply, false
+	}
+	/* WriteResponseBody triggers ProcessResponseBody if the bodylimit (SecResponseBodyLimit) is reached.
+	 * This means if we receive an interruption here it was evaluated and interrupted by response body processing.
+	 */
+	if interruption != nil {
+		f.handleInterruption(logger, PhaseResponseBody, interruption)
+		return api.LocalReply, false
+	}
+	return api.Continue, buffered == len(chunk)
+}
+
+// decodeCompressedHeaders prepares the inspection of a compressed request body.
+// A body with an encoding that can not be decompressed is handled according to
+// the configured policy. Compressed bodies are always buffered, also if the
+// request body is streamed otherwise.
+func (f *Filter) decodeCompressedHeaders(logger logging.Logger, contentEncoding string) api.StatusType {
+	decompression := f.Config.RequestDecompression()
+	if decompress.Supported(contentEncoding) {
+		logger.Debug("Compressed request detected, decompressing it for the inspection", "encoding", contentEncoding)
+		f.compressedRequest = &compressedBody{
+			encoding: contentEncoding,
+			// the size of the decompressed body is limited by SecRequestBodyLimit
+			limits: decompress.Limits{MaxSize: math.MaxInt, MaxRatio: decompression.MaxRatio},
+		}
+		return api.StopAndBuffer
+	}
+	switch decompression.UnsupportedEncoding {
+	case config.UnsupportedEncodingReject:
+		logger.Info("Unsupported request content encoding, rejecting the request", "encoding", contentEncoding)
+		f.Callbacks.DecoderFilterCallbacks().SendLocalReply(http.StatusUnsupportedMediaType, "", map[string][]string{}, 0, "")
+		return api.LocalReply
+	case config.UnsupportedEncodingPass:
+		logger.Info("Unsupported request content encoding, passing the request body without inspecting it", "encoding", contentEncoding)
+		f.requestBodyPassed = true
+		err := f.validateRequestBody(logger)
+		if err != nil {
+			logger.Error("request validation failed", "error", err.Error())
+			return api.LocalReply
+		}
+		return api.Continue
+	default:
+		logger.Debug("Unsupported request content encoding, inspecting the compressed body", "encoding", contentEncoding)
+		return api.StopAndBuffer
+	}
+}
+
+// decodeCompressedData collects a compressed request body and inspects the
+// decompressed body at the end of the request. The compressed data is passed
+// upstream unchanged. The decompression stops once the transaction does not
+// take more data because SecRequestBodyLimit was reached.
+func (f *Filter) decodeCompressedData(logger logging.Logger, buffer api.BufferInstance, endStream bool) api.StatusType {
+	body := f.compressedRequest
+	logger.Debug("Collecting compressed request data", "size", buffer.Len(), "encoding", body.encoding)
+	body.data = append(body.data, buffer.Bytes()...)
+	if !endStream {
+		return api.Continue
+	}
+	if status := f.writeCompressedRequest(logger); status != api.Continue {
+		return status
+	}
+	err := f.validateRequestBody(logger)
+	if err != nil {
+		logger.Error("request validation failed", "error", err.Error())
+		return api.LocalReply
+	}
+	return api.Continue
+}
+
+// writeCompressedRequest writes the decompressed request body to the transaction,
+// once the request ended with the last data or with trailers.
+func (f *Filter) writeCompressedRequest(logger logging.Logger) api.StatusType {
+	body := f.compressedRequest
+	defer func() { body.data = nil }()
+	reader, err := decompress.NewReader(body.data, body.encoding, body.limits)
+	if err != nil {
+		logger.Info("Invalid compressed request body", "encoding", body.encoding, "error", err.Error())
+		f.Callbacks.DecoderFilterCallbacks().SendLocalReply(http.StatusBadRequest, "", map[string][]string{}, 0, "")
+		return api.LocalReply
+	}
+	defer reader.Close()
+	chunk := make([]byte, decompressionChunkSize)
+	for done := false; !done; {
+		n, err := reader.Read(chunk)
+		if n > 0 {
+			interruption, buffered, err := f.tx.WriteRequestBody(chunk[:n])
+			logger.Debug("Buffered request data", "size", buffered)
+			if err != nil {
+				logger.Error("Failed to write request body", "error", err)
+				f.Callbacks.DecoderFilterCallbacks().SendLocalReply(http.StatusInternalServerError, "", map[string][]string{}, 0, "")
+				return api.LocalReply
+			}
+			/* WriteRequestBody triggers ProcessRequestBody if the bodylimit (SecRequestBodyLimit) is reached.
+			 * This means if we receive an interruption here it was evaluated and interrupted by request body processing.
+			 */
+			if interruption != nil {
+				f.handleInterruption(logger, PhaseRequestBody, interruption)
+				return api.LocalReply
+			}
+			if buffered < n {
+				logger.Debug("Request body limit reached, stopping the decompression")
+				break
+			}
+		}
+		switch {
+		case err == io.EOF:
+			done = true
+		case errors.Is(err, decompress.ErrLimitExceeded):
+			logger.Info("Decompressed request body exceeds the compression ratio limit", "encoding", body.encoding, "max_ratio", body.limits.MaxRatio)
+			f.Callbacks.DecoderFilterCallbacks().SendLocalReply(http.StatusRequestEntityTooLarge, "", map[string][]string{}, 0, "")
+			return api.LocalReply
+		case err != nil:
+			logger.Info("Invalid compressed request body", "encoding", body.encoding, "error", err.Error())
+			f.Callbacks.DecoderFilterCallbacks().SendLocalReply(http.StatusBadRequest, "", map[string][]string{}, 0, "")
+			return api.LocalReply
+		}
+	}
+	return api.Continue
+}
@@ -6,7 +6,9 @@ package filter
 
 import (
 	"bytes"
+	"coraza-waf/internal/clientip"
 	"coraza-waf/internal/config"
+	"coraza-waf/internal/decompress"
 	"coraza-waf/internal/logging"
 	"errors"
 	"fmt"
@@ -15,21 +17,46 @@ import (
 	"strconv"
 	"strings"
 
+	"github.com/corazawaf/coraza/v3"
 	"github.com/corazawaf/coraza/v3/types"
 	"github.com/envoyproxy/envoy/contrib/golang/common/go/api"
+	"github.com/google/uuid"
 )
 
 const HOSTPOSTSEPARATOR string = ":"
 
+// placeholderAddress is used for REMOTE_ADDR and SERVER_ADDR if the address of
+// the connection is not an IP address. It does not match loopback allowlists.
+const placeholderAddress = "0.0.0.0"
+
+// internalAddressPrefix starts the addresses of Envoy internal listeners.
+const internalAddressPrefix = "envoy://"
+
 type Filter struct {
 	api.PassThroughStreamFilter
 
-	Callbacks      api.FilterCallbackHandler
-	Config         config.Configuration
-	tx             types.Transaction
-	wasInterrupted bool
-	httpProtocol   string
-	connection     connectionState
+	Callbacks          api.FilterCallbackHandler
+	Config             config.Configuration
+	tx                 types.Transaction
+	waf                coraza.WAF
+	request            request
+	route              config.RouteContext
+	grpc               *grpcStream
+	websocket          *websocketStream
+	sse                *sseStream
+	streaming          *requestStreaming
+	compressedRequest  *compressedBody
+	compressedResponse *compressedBody
+	requestBodyPassed  bool
+	responseStatus     int
+	wasInterrupted     bool
+	httpProtocol       string
+	connection         connectionState
+	requestID          string
+	requestMethod      string
+	requestAccept      string
+	isGrpc             bool
+	blockResponse      *config.BlockResponse
 
 	Logger logging.Logger
 }
@@ -39,7 +66,10 @@ func (f *Filter) DecodeHeaders(headerMap api.RequestHeaderMap, endStream bool) a
 	if id, exist := headerMap.Get("x-request-id"); exist {
 		requestId = id
 	}
+	f.requestID = requestId
 	f.Logger = f.Logger.With("request-id", requestId)
+	f.route = f.routeContext()
+	f.Logger = f.Logger.With(f.route.LogArgs()...)
 	logger := f.Logger.With("phase", "DecodeHeaders")
 	f.connection = connectionStateHttp
 	host := headerMap.Host()
@@ -57,20 +87,26 @@ func (f *Filter) DecodeHeaders(headerMap api.RequestHeaderMap, endStream bool) a
 		return api.Continue
 	}
 	// Process connection (will not block)
-	srcIP, srcPort, err := f.splitHostPort(f.Callbacks.StreamInfo().DownstreamRemoteAddress())
+	srcIP, srcPort, err := f.remoteAddress(logger, f.Callbacks.StreamInfo().DownstreamRemoteAddress())
 	if err != nil {
 		logger.Error("could not parse IP and port for remote address", "error", err.Error())
 		return api.LocalReply
 	}
-	destIP, destPort, err := f.splitHostPort(f.Callbacks.StreamInfo().DownstreamLocalAddress())
+	destIP, destPort, err := f.localAddress(logger, f.Callbacks.StreamInfo().DownstreamLocalAddress())
 	if err != nil {
 		logger.Error("could not parse IP and port for local address", "error", err.Error())
 		return api.LocalReply
 	}
+	srcIP, srcPort = f.resolveClient(logger, headerMap, srcIP, srcPort)
+	f.Logger = f.Logger.With("client", srcIP)
+	logger = f.Logger.With("phase", "DecodeHeaders")
 	f.tx.ProcessConnection(srcIP, srcPort, destIP, destPort)
+	f.request.srcIP, f.request.srcPort, f.request.destIP, f.request.destPort = srcIP, srcPort, destIP, destPort
 	// Process URI (will not block)
 	path := headerMap.Path()
 	method := headerMap.Method()
+	f.requestMethod = strings.ToUpper(method)
+	f.request.path, f.request.method = path, method
 	if strings.EqualFold(method, "connect") {
 		f.connection = connectionStateHttpTunnel
 	}
@@ -84,6 +120,9 @@ func (f *Filter) DecodeHeaders(headerMap api.RequestHeaderMap, endStream bool) a
 	// Process request headers (might block)
 	upgrade_websocket_header := false
 	connection_upgrade_header := false
+	grpcEncoding := ""
+	contentEncoding := ""
+	contentType := ""
 	headerMap.Range(func(key, value string) bool {
 		// check for WS upgrade request
 		if key == "upgrade" && strings.Contains(strings.ToLower(value), "websocket") {
@@ -92,7 +131,24 @@ func (f *Filter) DecodeHeaders(headerMap api.RequestHeaderMap, endStream bool) a
 		if key == "connection" && strings.Contains(strings.ToLower(value), "upgrade") {
 			connection_upgrade_header = true
 		}
+		// remember how the client wants to be answered if the request is blocked
+		if key == "accept" {
+			f.requestAccept = value
+		}
+		if key == "content-type" {
+			contentType = value
+			if isGrpcContentType(value) {
+				f.isGrpc = true
+			}
+		}
+		if key == "grpc-encoding" {
+			grpcEncoding = strings.ToLower(value)
+		}
+		if key == "content-encoding" {
+			contentEncoding = value
+		}
 		f.tx.AddRequestHeader(key, value)
+		f.request.headers = append(f.request.headers, [2]string{key, value})
 		return true
 	})
 	if upgrade_websocket_header && connection_upgrade_header {
@@ -115,6 +171,31 @@ func (f *Filter) DecodeHeaders(headerMap api.RequestHeaderMap, endStream bool) a
 		return api.Continue
 	}
 
+	if f.isGrpc && f.tx.IsRequestBodyAccessible() {
+		// the messages of a gRPC request are inspected one by one in DecodeData,
+		// the request itself is inspected without a body
+		logger.Debug("gRPC request detected, inspecting its messages")
+		f.grpc = &grpcStream{encoding: grpcEncoding}
+		err := f.validateRequestBody(logger)
+		if err != nil {
+			logger.Error("request validation failed", "error", err.Error())
+			return api.LocalReply
+		}
+		// the headers are passed upstream with the first inspected data
+		return api.StopAndBufferWatermark
+	}
+
+	if !decompress.Identity(contentEncoding) && f.tx.IsRequestBodyAccessible() && f.connection.IsHttp() {
+		return f.decodeCompressedHeaders(logger, contentEncoding)
+	}
+
+	if interval := f.Config.RequestBodyStreamingInterval(); interval > 0 && f.tx.IsRequestBodyAccessible() && f.connection.IsHttp() {
+		// the body is inspected while it is passed upstream in DecodeData
+		logger.Debug("Streaming request body data", "interval", interval)
+		f.streaming = f.newRequestStreaming(logger, interval, contentType)
+		return api.Continue
+	}
+
 	if f.tx.IsRequestBodyAccessible() && f.connection.IsHttp() {
 		logger.Debug("Buffering request body data")
 		return api.StopAndBuffer
@@ -129,6 +210,10 @@ func (f *Filter) DecodeData(buffer api.BufferInstance, endStream bool) api.Statu
 		f.Callbacks.DecoderFilterCallbacks().SendLocalReply(http.StatusForbidden, "", map[string][]string{}, 0, "interruption-already-handled")
 		return api.LocalReply
 	}
+	// the messages are inspected by the WAF of the websocket directive, regardless of the transaction of the upgrade request
+	if f.websocket != nil {
+		return f.decodeWebsocketData(logger, buffer)
+	}
 	if f.tx.IsRuleEngineOff() {
 		return api.Continue
 	}
@@ -141,6 +226,18 @@ func (f *Filter) DecodeData(buffer api.BufferInstance, endStream bool) api.Statu
 		}
 		return api.Continue
 	}
+	if f.grpc != nil {
+		return f.decodeGrpcData(logger, buffer, endStream)
+	}
+	if f.requestBodyPassed {
+		return api.Continue
+	}
+	if f.compressedRequest != nil {
+		return f.decodeCompressedData(logger, buffer, endStream)
+	}
+	if f.streaming != nil {
+		return f.decodeStreamingData(logger, buffer, endStream)
+	}
 	logger.Debug("Processing incoming request data", "size", buffer.Len())
 	if buffer.Len() > 0 {
 		// Write request body into waf
@@ -171,6 +268,43 @@ func (f *Filter) DecodeData(buffer api.BufferInstance, endStream bool) api.Statu
 	return api.Continue
 }
 
+// DecodeTrailers is called instead of DecodeData with endStream if the request ends with trailers, e.g. for gRPC.
+// The trailers are added to the request headers and the request body is processed.
+func (f *Filter) DecodeTrailers(trailerMap api.RequestTrailerMap) api.StatusType {
+	logger := f.Logger.With("phase", "DecodeTrailers")
+	if f.wasInterrupted {
+		f.Callbacks.DecoderFilterCallbacks().SendLocalReply(http.StatusForbidden, "", map[string][]string{}, 0, "interruption-already-handled")
+		return api.LocalReply
+	}
+	if f.tx == nil || f.tx.IsRuleEngineOff() {
+		return api.Continue
+	}
+	trailerMap.Range(func(key, value string) bool {
+		f.tx.AddRequestHeader(key, value)
+		return true
+	})
+	if f.streaming != nil {
+		// the rest of a streamed body is inspected before the request
+		if err := f.inspectStreamingWindow(logger); err != nil {
+			logger.Error("request validation failed", "error", err.Error())
+			return api.LocalReply
+		}
+		f.streaming.window = nil
+	}
+	if f.compressedRequest != nil {
+		// the collected body is decompressed like at the end of the data
+		if status := f.writeCompressedRequest(logger); status != api.Continue {
+			return status
+		}
+	}
+	err := f.validateRequestBody(logger)
+	if err != nil {
+		logger.Error("request validation failed", "error", err.Error())
+		return api.LocalReply
+	}
+	return api.Continue
+}
+
 func (f *Filter) EncodeHeaders(headerMap api.ResponseHeaderMap, endStream bool) api.StatusType {
 	logger := f.Logger.With("phase", "EncodeHeaders")
 	if f.wasInterrupted {
@@ -187,10 +321,20 @@ func (f *Filter) EncodeHeaders(headerMap api.ResponseHeaderMap, endStream bool)
 	if !b {
 		code = 0
 	}
+	f.responseStatus = int(code)
 	// Process response headers (might block)
 	upgrade_websocket_header := false
 	connection_upgrade_header := false
+	websocketExtensions := ""
+	eventStream := false
+	contentEncoding := ""
 	headerMap.Range(func(key, value string) bool {
+		if key == "content-encoding" {
+			contentEncoding = value
+		}
+		if key == "content-type" && strings.HasPrefix(strings.ToLower(value), contentTypeEventStream) {
+			eventStream = true
+		}
 		// check for WS upgrade response
 		if f.connection.IsWebsocketUpgradeRequested() {
 			if key == "upgrade" && strings.Contains(strings.ToLower(value), "websocket") {
@@ -200,6 +344,9 @@ func (f *Filter) EncodeHeaders(headerMap api.ResponseHeaderMap, endStream bool)
 				connection_upgrade_header = true
 
 			}
+			if key == "sec-websocket-extensions" {
+				websocketExtensions = value
+			}
 		}
 		f.tx.AddResponseHeader(key, value)
 		return true
@@ -207,6 +354,7 @@ func (f *Filter) EncodeHeaders(headerMap api.ResponseHeaderMap, endStream bool)
 	if upgrade_websocket_header && connection_upgrade_header {
 		logger.Debug("Websocket upgrade request detected")
 		f.connection = connectionStateWebsocketConnection
+		f.websocket = f.newWebsocketStream(logger, websocketExtensions)
 	}
 	interruption := f.tx.ProcessResponseHeaders(int(code), f.httpProtocol)
 	if interruption != nil {
@@ -223,7 +371,22 @@ func (f *Filter) EncodeHeaders(headerMap api.ResponseHeaderMap, endStream bool)
 		return api.Continue
 	}
 
+	if eventStream && f.Config.InspectSSEEvents() && f.tx.IsResponseBodyAccessible() {
+		// the events are inspected one by one in EncodeData, buffering the response would delay them
+		logger.Debug("Event stream detected, inspecting its events")
+		f.sse = &sseStream{}
+		return api.Continue
+	}
+
 	if f.tx.IsResponseBodyAccessible() && f.connection.IsHttp() {
+		if !decompress.Identity(contentEncoding) {
+			if decompress.Supported(contentEncoding) {
+				logger.Debug("Compressed response detected, decompressing it for the inspection", "encoding", contentEncoding)
+				f.compressedResponse = &compressedBody{encoding: contentEncoding, limits: f.Config.ResponseDecompressionLimits()}
+			} else {
+				logger.Debug("Unsupported response content encoding, inspecting the compressed body", "encoding", contentEncoding)
+			}
+		}
 		logger.Debug("Buffering response headers")
 		return api.StopAndBuffer
 	}
@@ -237,6 +400,9 @@ func (f *Filter) EncodeData(buffer api.BufferInstance, endStream bool) api.Statu
 	// there are cases (e.g. malformed HTTP request) where envoy will automatically
 	// jump from the decoding phase to the encoding phase
 	if f.tx == nil || f.tx.IsRuleEngineOff() || f.connection.IsWebsocket() {
+		if f.websocket != nil {
+			return f.encodeWebsocketData(logger, buffer)
+		}
 		if f.connection.IsWebsocket() {
 			logger.Debug("Skip response body processing (websocket connection)")
 		}
@@ -246,6 +412,9 @@ func (f *Filter) EncodeData(buffer api.BufferInstance, endStream bool) api.Statu
 		f.Callbacks.EncoderFilterCallbacks().SendLocalReply(http.StatusForbidden, "", map[string][]string{}, 0, "")
 		return api.LocalReply
 	}
+	if f.sse != nil {
+		return f.encodeSSEData(logger, buffer, endStream)
+	}
 	logger.Debug("Processing incoming response data", "size", buffer.Len())
 	if !f.tx.IsResponseBodyAccessible() {
 		logger.Debug("Skipping response body processing, SecResponseBodyAccess is off")
@@ -256,7 +425,11 @@ func (f *Filter) EncodeData(buffer api.BufferInstance, endStream bool) api.Statu
 		}
 		return api.Continue
 	}
-	if buffer.Len() > 0 {
+	if f.compressedResponse != nil {
+		if status := f.writeCompressedResponseData(logger, buffer, endStream); status != api.Continue {
+			return status
+		}
+	} else if buffer.Len() > 0 {
 		// Write response body into waf
 		interruption, buffered, err := f.tx.WriteResponseBody(buffer.Bytes())
 		logger.Debug("Buffered response body data", "size", buffered)
@@ -289,6 +462,44 @@ func (f *Filter) EncodeData(buffer api.BufferInstance, endStream bool) api.Statu
 	return api.Continue
 }
 
+// EncodeTrailers is called instead of EncodeData with endStream if the response ends with trailers, e.g. for gRPC.
+// The trailers are added to the response headers and the response body is processed.
+func (f *Filter) EncodeTrailers(trailerMap api.ResponseTrailerMap) api.StatusType {
+	logger := f.Logger.With("phase", "EncodeTrailers")
+	// the nil check here MUST NEVER be removed
+	// there are cases (e.g. malformed HTTP request) where envoy will automatically
+	// jump from the decoding phase to the encoding phase
+	if f.tx == nil || f.tx.IsRuleEngineOff() || f.connection.IsWebsocket() {
+		return api.Continue
+	}
+	if f.wasInterrupted {
+		f.Callbacks.EncoderFilterCallbacks().SendLocalReply(http.StatusForbidden, "", map[string][]string{}, 0, "")
+		return api.LocalReply
+	}
+	trailerMap.Range(func(key, value string) bool {
+		f.tx.AddResponseHeader(key, value)
+		return true
+	})
+	if f.sse != nil {
+		if status := f.encodeSSETrailers(logger); status != api.Continue {
+			return status
+		}
+	}
+	if f.compressedResponse != nil {
+		// the collected body is decompressed like at the end of the data
+		if status := f.writeCompressedResponse(logger); status != api.Continue {
+			return status
+		}
+	}
+	// if the body was already sent downstream, envoy resets the stream instead of sending the local reply
+	err := f.validateResponseBody(logger)
+	if err != nil {
+		logger.Error("response validation failed", "error", err.Error())
+		return api.LocalReply
+	}
+	return api.Continue
+}
+
 func (f *Filter) OnDestroy(reason api.DestroyReason) {
 	logger := f.Logger.With("phase", "OnDestroy")
 	if f.tx == nil {
@@ -297,6 +508,7 @@ func (f *Filter) OnDestroy(reason api.DestroyReason) {
 
 	f.tx.ProcessLogging()
 	_ = f.tx.Close()
+	config.UnregisterTransaction(f.tx.ID())
 	logger.Info("Transaction finished")
 }
 
@@ -304,33 +516,33 @@ func (f *Filter) initializeTx(logger logging.Logger, headerMap api.RequestHeader
 	xReqId, exist := headerMap.Get("x-request-id")
 	if !exist {
 		logger.Error("Error getting x-request-id header")
-		xReqId = ""
-	}
-	waf := f.Config.WafMaps[f.Config.DefaultDirective]
-	wafFound := false
-	ruleName, ok := f.Config.HostDirectiveMap[host]
-	if ok {
-		wafFound = true
-		waf = f.Config.WafMaps[ruleName]
-	} else {
-		hostWithoutPort, _, err := net.SplitHostPort(host)
-		if err == nil {
-			ruleName, ok = f.Config.HostDirectiveMap[hostWithoutPort]
-			if ok {
-				wafFound = true
-				waf = f.Config.WafMaps[ruleName]
-			}
-		}
 	}
-	if wafFound {
+	directive := f.Config.DefaultDirective
+	ruleName, wafFound := f.Config.DirectiveForHost(host)
+	if routeRuleName, ok := f.Config.DirectiveForRoute(headerMap.Method(), headerMap.Path()); ok {
+		directive = routeRuleName
+		logger.Debug("using route configuration for tx", "waf", routeRuleName)
+	} else if wafFound {
+		directive = ruleName
 		logger.Debug("using host configuration for tx", "waf", ruleName)
 	} else {
 		logger.Debug("using default host configuration for tx", "waf", f.Config.DefaultDirective)
 	}
-	// the ID of the transaction is set to the ID of the request
-	// see errorCallback() in parse.go for more details
-	f.tx = waf.NewTransactionWithID(xReqId)
+	waf := f.Config.Waf(directive)
+	if waf == nil {
+		// a configuration without directives is only valid as per route configuration
+		f.Callbacks.DecoderFilterCallbacks().SendLocalReply(http.StatusInternalServerError, "", map[string][]string{}, 0, "")
+		return errors.New("no directives configured")
+	}
+	// the ID of the transaction is generated, the x-request-id can be sent by the client.
+	// errorCallback() in config.go looks the request ID and route context up by the ID
+	f.tx = waf.NewTransactionWithID(uuid.NewString())
+	config.RegisterTransaction(f.tx.ID(), xReqId, f.route)
+	f.Logger = f.Logger.With("tx", f.tx.ID())
+	f.waf = waf
+	f.blockResponse = f.Config.BlockResponse(directive)
 	f.tx.AddRequestHeader("Host", host)
+	f.request.headers = append(f.request.headers, [2]string{"Host", host})
 	var server = host
 	var err error
 	if strings.Contains(host, HOSTPOSTSEPARATOR) {
@@ -341,6 +553,9 @@ func (f *Filter) initializeTx(logger logging.Logger, headerMap api.RequestHeader
 		}
 	}
 	f.tx.SetServerName(server)
+	f.request.server = server
+	f.request.variables = append(routeVariables(f.route), f.tlsVariables(logger, server)...)
+	setTxVariables(f.tx, f.request.variables)
 
 	return nil
 }
@@ -375,20 +590,79 @@ func (f *Filter) validateResponseBody(logger logging.Logger) error {
 
 func (f *Filter) handleInterruption(logger logging.Logger, phase phase, interruption *types.Interruption) {
 	f.wasInterrupted = true
+	message := "Transaction interrupted"
+	if interruption.Action == actionDrop {
+		message = "Transaction dropped"
+	}
 	logger.Info(
-		"Transaction interrupted",
+		message,
 		"phase", phase.String(),
 		"ruleID", interruption.RuleID,
 		"action", interruption.Action,
 		"status", interruption.Status,
 	)
 
+	reply := f.blockReply(logger, phase, interruption)
 	switch phase {
 	case PhaseRequestHeader, PhaseRequestBody:
-		f.Callbacks.DecoderFilterCallbacks().SendLocalReply(interruption.Status, "", map[string][]string{}, 0, "")
+		f.Callbacks.DecoderFilterCallbacks().SendLocalReply(reply.status, reply.body, reply.headers, reply.grpcStatus, reply.details)
 	case PhaseResponseHeader, PhaseResponseBody:
-		f.Callbacks.EncoderFilterCallbacks().SendLocalReply(interruption.Status, "", map[string][]string{}, 0, "")
+		f.Callbacks.EncoderFilterCallbacks().SendLocalReply(reply.status, reply.body, reply.headers, reply.grpcStatus, reply.details)
+	}
+}
+
+// remoteAddress returns the IP and port of the downstream remote address. For a
+// connection without IP address, e.g. on a Unix domain socket or an Envoy internal
+// listener, the original client address is read from the configured filter state
+// object. Without it the placeholder address and port 0 are returned.
+func (f *Filter) remoteAddress(logger logging.Logger, address string) (string, int, error) {
+	if !isPipeOrInternalAddress(address) {
+		return f.splitHostPort(address)
+	}
+	if key := f.Config.ClientAddressFilterState; key != "" {
+		if value := f.Callbacks.StreamInfo().FilterState().GetString(key); value != "" {
+			if ip, port, ok := clientip.ParseAddress(value); ok {
+				logger.Debug("client address read from filter state", "address", address, "key", key, "client", ip)
+				return ip, port, nil
+			}
+			logger.Warn("invalid client address in filter state, using the placeholder address", "key", key, "value", value)
+		}
+	}
+	logger.Debug("remote address is not an IP address, using the placeholder address", "address", address)
+	return placeholderAddress, 0, nil
+}
+
+// localAddress returns the IP and port of the downstream local address. For a
+// connection without IP address the placeholder address and port 0 are returned.
+func (f *Filter) localAddress(logger logging.Logger, address string) (string, int, error) {
+	if !isPipeOrInternalAddress(address) {
+		return f.splitHostPort(address)
+	}
+	logger.Debug("local address is not an IP address, using the placeholder address", "address", address)
+	return placeholderAddress, 0, nil
+}
+
+// isPipeOrInternalAddress reports whether an address of envoy is the path of a Unix domain
+// socket (/path, @abstract, empty for an unnamed peer) or an internal listener (envoy://name/id).
+func isPipeOrInternalAddress(address string) bool {
+	return address == "" || strings.HasPrefix(address, "/") || strings.HasPrefix(address, "@") || strings.HasPrefix(address, internalAddressPrefix)
+}
+
+// resolveClient returns the client address of a request received from a trusted
+// proxy, read from the configured header. The peer address is returned for other
+// requests and if the header does not contain a valid address.
+func (f *Filter) resolveClient(logger logging.Logger, headerMap api.RequestHeaderMap, peerIP string, peerPort int) (string, int) {
+	resolver := f.Config.ClientIP()
+	if !resolver.Trusted(peerIP) {
+		return peerIP, peerPort
+	}
+	ip, port, ok := resolver.Resolve(headerMap.Values(string(resolver.Header)))
+	if !ok {
+		logger.Debug("no valid client address in header, using the address of the trusted proxy", "header", resolver.Header, "proxy", peerIP)
+		return peerIP, peerPort
 	}
+	logger.Debug("client address resolved from header", "header", resolver.Header, "proxy", peerIP, "client", ip)
+	return ip, port
 }
 
 func (f *Filter) splitHostPort(hostPortCombination string) (string, int, error) {
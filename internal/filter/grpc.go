@@ -0,0 +1,68 @@
+// Copyright © 2026 United Security Providers AG, Switzerland
+// SPDX-License-Identifier: Apache-2.0
+
+package filter
+
+import (
+	"net/http"
+
+	"github.com/envoyproxy/envoy/contrib/golang/common/go/api"
+
+	"coraza-waf/internal/grpcbody"
+	"coraza-waf/internal/logging"
+)
+
+// grpcStream is the state of a gRPC request whose messages are inspected one by one.
+type grpcStream struct {
+	framer   grpcbody.Framer
+	encoding string
+	messages int
+}
+
+// decodeGrpcData inspects every gRPC message completed by the data, each in its
+// own transaction. The data is passed upstream after its messages are inspected.
+// A message split across several data frames is only complete, and usable by the
+// upstream, with its last frame, which is not passed before the message is inspected.
+func (f *Filter) decodeGrpcData(logger logging.Logger, buffer api.BufferInstance, endStream bool) api.StatusType {
+	messages, err := f.grpc.framer.Write(buffer.Bytes())
+	if err != nil {
+		logger.Error("invalid gRPC request", "error", err.Error())
+		f.Callbacks.DecoderFilterCallbacks().SendLocalReply(http.StatusBadRequest, "invalid gRPC request", map[string][]string{}, grpcStatusInvalidArgument, "")
+		return api.LocalReply
+	}
+	for _, message := range messages {
+		f.grpc.messages++
+		payload, err := message.Payload(f.grpc.encoding)
+		if err != nil {
+			logger.Error("invalid gRPC message", "message", f.grpc.messages, "error", err.Error())
+			f.Callbacks.DecoderFilterCallbacks().SendLocalReply(http.StatusBadRequest, "invalid gRPC request", map[string][]string{}, grpcStatusInvalidArgument, "")
+			return api.LocalReply
+		}
+		contentType := ""
+		if f.Config.GrpcDescriptors != nil {
+			decoded, err := f.Config.GrpcDescriptors.RequestToJSON(f.request.path, payload)
+			if err != nil {
+				logger.Debug("could not decode gRPC message, inspecting the protobuf message", "message", f.grpc.messages, "error", err.Error())
+			} else {
+				payload, contentType = decoded, "application/json"
+			}
+		}
+		logger.Debug("Inspecting gRPC message", "message", f.grpc.messages, "size", len(payload))
+		interruption, err := f.inspectRequestMessage(f.waf, payload, contentType)
+		if err != nil {
+			logger.Error("Failed to inspect gRPC message", "message", f.grpc.messages, "error", err.Error())
+			f.Callbacks.DecoderFilterCallbacks().SendLocalReply(http.StatusInternalServerError, "", map[string][]string{}, grpcStatusInternal, "")
+			return api.LocalReply
+		}
+		if interruption != nil {
+			f.handleInterruption(logger, PhaseRequestBody, interruption)
+			return api.LocalReply
+		}
+	}
+	if endStream && f.grpc.framer.Pending() {
+		logger.Error("invalid gRPC request", "error", "the request ends in the middle of a message")
+		f.Callbacks.DecoderFilterCallbacks().SendLocalReply(http.StatusBadRequest, "invalid gRPC request", map[string][]string{}, grpcStatusInvalidArgument, "")
+		return api.LocalReply
+	}
+	return api.Continue
+}
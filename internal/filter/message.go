@@ -0,0 +1,92 @@
+// Copyright © 2026 United Security Providers AG, Switzerland
+// SPDX-License-Identifier: Apache-2.0
+
+package filter
+
+import (
+	"strings"
+
+	"github.com/corazawaf/coraza/v3"
+	"github.com/corazawaf/coraza/v3/types"
+)
+
+// request holds what the transaction of a request was initialized with, so a
+// message of the request can be inspected in a transaction of its own.
+type request struct {
+	srcIP, destIP     string
+	srcPort, destPort int
+	path, method      string
+	server            string
+	headers           [][2]string
+	variables         [][2]string
+}
+
+// inspectRequestMessage evaluates the rules of the request body phase against a
+// single message of a stream, e.g. a gRPC message. The message is inspected in its
+// own transaction of the WAF with the connection, URI and headers of the request,
+// so the body processors see one complete body. If contentType is set it replaces
+// the content-type of the request, so the rules select the matching body processor.
+func (f *Filter) inspectRequestMessage(waf coraza.WAF, body []byte, contentType string) (*types.Interruption, error) {
+	tx, interruption := f.newMessageTransaction(waf, contentType)
+	defer closeMessageTransaction(tx)
+	if interruption != nil {
+		return interruption, nil
+	}
+	interruption, _, err := tx.WriteRequestBody(body)
+	if err != nil || interruption != nil {
+		return interruption, err
+	}
+	return tx.ProcessRequestBody()
+}
+
+// inspectResponseMessage evaluates the rules of the response body phase against
+// a single message sent downstream, e.g. a WebSocket message. The message is
+// inspected like the body of a response with the status of the response and
+// the content type.
+func (f *Filter) inspectResponseMessage(waf coraza.WAF, body []byte, contentType string) (*types.Interruption, error) {
+	tx, interruption := f.newMessageTransaction(waf, "")
+	defer closeMessageTransaction(tx)
+	if interruption != nil {
+		return interruption, nil
+	}
+	interruption, err := tx.ProcessRequestBody()
+	if err != nil || interruption != nil {
+		return interruption, err
+	}
+	tx.AddResponseHeader("Content-Type", contentType)
+	if interruption := tx.ProcessResponseHeaders(f.responseStatus, f.httpProtocol); interruption != nil {
+		return interruption, nil
+	}
+	interruption, _, err = tx.WriteResponseBody(body)
+	if err != nil || interruption != nil {
+		return interruption, err
+	}
+	return tx.ProcessResponseBody()
+}
+
+// newMessageTransaction returns a transaction of the WAF with the request headers processed.
+func (f *Filter) newMessageTransaction(waf coraza.WAF, contentType string) (types.Transaction, *types.Interruption) {
+	tx := waf.NewTransactionWithID(f.tx.ID())
+	setTxVariables(tx, f.request.variables)
+	tx.ProcessConnection(f.request.srcIP, f.request.srcPort, f.request.destIP, f.request.destPort)
+	tx.ProcessURI(f.request.path, f.request.method, f.httpProtocol)
+	replaced := false
+	for _, header := range f.request.headers {
+		if contentType != "" && strings.EqualFold(header[0], "content-type") {
+			tx.AddRequestHeader(header[0], contentType)
+			replaced = true
+			continue
+		}
+		tx.AddRequestHeader(header[0], header[1])
+	}
+	if contentType != "" && !replaced {
+		tx.AddRequestHeader("Content-Type", contentType)
+	}
+	tx.SetServerName(f.request.server)
+	return tx, tx.ProcessRequestHeaders()
+}
+
+func closeMessageTransaction(tx types.Transaction) {
+	tx.ProcessLogging()
+	_ = tx.Close()
+}
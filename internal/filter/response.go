@@ -0,0 +1,246 @@
+// Copyright © 2026 United Security Providers AG, Switzerland
+// SPDX-License-Identifier: Apache-2.0
+
+package filter
+
+import (
+	"fmt"
+	"html"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/corazawaf/coraza/v3/types"
+	jsoniter "github.com/json-iterator/go"
+
+	"coraza-waf/internal/config"
+	"coraza-waf/internal/logging"
+)
+
+type replyFormat int
+
+const (
+	replyFormatEmpty replyFormat = iota
+	replyFormatHTML
+	replyFormatProblemJSON
+)
+
+const (
+	actionDrop     = "drop"
+	actionRedirect = "redirect"
+
+	// dropDetails is the response code detail of a dropped stream, available as %RESPONSE_CODE_DETAILS% in the access log
+	dropDetails = "coraza_waf_drop"
+)
+
+const (
+	contentTypeHTML        = "text/html; charset=utf-8"
+	contentTypeProblemJSON = "application/problem+json"
+	grpcContentType        = "application/grpc"
+)
+
+// gRPC status codes, see https://grpc.github.io/grpc/core/md_doc_statuscodes.html
+const (
+	grpcStatusUnknown           int64 = 2
+	grpcStatusInvalidArgument   int64 = 3
+	grpcStatusNotFound          int64 = 5
+	grpcStatusPermissionDenied  int64 = 7
+	grpcStatusResourceExhausted int64 = 8
+	grpcStatusUnimplemented     int64 = 12
+	grpcStatusInternal          int64 = 13
+	grpcStatusUnavailable       int64 = 14
+	grpcStatusUnauthenticated   int64 = 16
+)
+
+// localReply is the response sent to the client instead of the upstream response.
+type localReply struct {
+	status     int
+	body       string
+	headers    map[string][]string
+	grpcStatus int64
+	details    string
+}
+
+// resetReply returns a reply without a body and drains the downstream connection:
+// envoy closes HTTP/1.x connections after the reply and sends a GOAWAY on HTTP/2
+// and HTTP/3 connections. Envoy resets the upstream request if it was already sent.
+func (f *Filter) resetReply(status int, details string) localReply {
+	if status < 200 {
+		status = http.StatusForbidden
+	}
+	f.Callbacks.StreamInfo().DrainConnectionUponCompletion()
+	return localReply{
+		status:  status,
+		headers: map[string][]string{},
+		details: details,
+	}
+}
+
+// problemDetails is an RFC 9457 problem details document.
+type problemDetails struct {
+	Type      string `json:"type"`
+	Title     string `json:"title"`
+	Status    int    `json:"status"`
+	Detail    string `json:"detail"`
+	RequestID string `json:"request_id"`
+}
+
+// blockReply returns the reply for an interrupted transaction. A drop action
+// gets an empty reply, without any block response or negotiation, which closes
+// the downstream connection. gRPC requests
+// always get a trailers-only reply with a grpc-status, so gRPC clients can
+// handle it. A redirect action redirects to its target. Otherwise the block
+// response of the directive set is used, and without one the format is
+// negotiated with the Accept header of the request.
+func (f *Filter) blockReply(logger logging.Logger, phase phase, interruption *types.Interruption) localReply {
+	if interruption.Action == actionDrop {
+		return f.resetReply(interruption.Status, dropDetails)
+	}
+
+	if phase == PhaseRequestBody && f.streaming != nil {
+		// the request was partly passed upstream, envoy resets the upstream request with the reply
+		return f.resetReply(interruption.Status, streamingResetDetails)
+	}
+
+	if f.isGrpc {
+		// envoy turns a local reply to a gRPC request into a trailers-only reply with the body as grpc-message
+		return localReply{
+			status:     interruption.Status,
+			body:       fmt.Sprintf("request blocked by the web application firewall, reference %s", f.requestID),
+			headers:    map[string][]string{},
+			grpcStatus: grpcStatusFromHTTP(interruption.Status),
+		}
+	}
+
+	if interruption.Action == actionRedirect && interruption.Data != "" {
+		return localReply{
+			status:  redirectStatus(interruption.Status, f.requestMethod),
+			headers: map[string][]string{"location": {interruption.Data}},
+		}
+	}
+
+	if f.blockResponse != nil {
+		status, body, headers, err := f.blockResponse.Render(config.BlockResponseData{
+			RequestID:     f.requestID,
+			TransactionID: f.tx.ID(),
+			RuleID:        interruption.RuleID,
+			Phase:         phase.String(),
+			Status:        interruption.Status,
+		})
+		if err == nil {
+			return localReply{status: status, body: body, headers: headers}
+		}
+		logger.Error("could not render block response, sending an empty response", "error", err.Error())
+		return localReply{status: interruption.Status, headers: map[string][]string{}}
+	}
+
+	reply := localReply{status: interruption.Status, headers: map[string][]string{}}
+	title := http.StatusText(interruption.Status)
+	if title == "" {
+		title = "Request blocked"
+	}
+	switch negotiateReplyFormat(f.requestAccept) {
+	case replyFormatHTML:
+		reply.headers["content-type"] = []string{contentTypeHTML}
+		reply.body = fmt.Sprintf(
+			"<!DOCTYPE html>\n<html><head><title>%d %s</title></head><body><h1>%s</h1><p>The request was blocked by the web application firewall.</p><p>Reference: %s</p></body></html>\n",
+			interruption.Status, html.EscapeString(title), html.EscapeString(title), html.EscapeString(f.requestID),
+		)
+	case replyFormatProblemJSON:
+		body, err := jsoniter.ConfigCompatibleWithStandardLibrary.Marshal(problemDetails{
+			Type:      "about:blank",
+			Title:     title,
+			Status:    interruption.Status,
+			Detail:    "The request was blocked by the web application firewall.",
+			RequestID: f.requestID,
+		})
+		if err != nil {
+			logger.Error("could not render problem details, sending an empty response", "error", err.Error())
+			return reply
+		}
+		reply.headers["content-type"] = []string{contentTypeProblemJSON}
+		reply.body = string(body)
+	}
+	return reply
+}
+
+// negotiateReplyFormat picks the reply format with the highest quality in the
+// Accept header. Only explicitly accepted formats are used, a client without
+// an Accept header or accepting only */* gets an empty body.
+func negotiateReplyFormat(accept string) replyFormat {
+	format, bestQuality := replyFormatEmpty, 0.0
+	for _, mediaRange := range strings.Split(accept, ",") {
+		mediaType, params, err := mime.ParseMediaType(strings.TrimSpace(mediaRange))
+		if err != nil {
+			continue
+		}
+		quality := 1.0
+		if q, ok := params["q"]; ok {
+			if quality, err = strconv.ParseFloat(q, 64); err != nil {
+				continue
+			}
+		}
+		var candidate replyFormat
+		switch mediaType {
+		case "text/html", "application/xhtml+xml", "text/*":
+			candidate = replyFormatHTML
+		case "application/problem+json", "application/json", "application/*":
+			candidate = replyFormatProblemJSON
+		default:
+			continue
+		}
+		if quality > bestQuality {
+			format, bestQuality = candidate, quality
+		}
+	}
+	return format
+}
+
+// redirectStatus returns the status of a redirect interruption. A redirect
+// status set by the rule is kept, otherwise a GET or HEAD request is redirected
+// with 302 and any other method with 303, so the client does not send the
+// blocked request body to the target again.
+func redirectStatus(status int, method string) int {
+	switch status {
+	case http.StatusMovedPermanently, http.StatusFound, http.StatusSeeOther, http.StatusTemporaryRedirect, http.StatusPermanentRedirect:
+		return status
+	}
+	if method == http.MethodGet || method == http.MethodHead {
+		return http.StatusFound
+	}
+	return http.StatusSeeOther
+}
+
+// isGrpcContentType reports whether a content type is application/grpc, optionally with
+// a message format like application/grpc+proto or parameters. gRPC-Web requests, e.g.
+// application/grpc-web+proto, are plain HTTP requests for the filter and do not match.
+func isGrpcContentType(contentType string) bool {
+	contentType = strings.ToLower(contentType)
+	rest, ok := strings.CutPrefix(contentType, grpcContentType)
+	return ok && (rest == "" || rest[0] == '+' || rest[0] == ';')
+}
+
+// grpcStatusFromHTTP maps the status of an interruption to a gRPC status, like envoy does for local replies.
+func grpcStatusFromHTTP(status int) int64 {
+	switch status {
+	case http.StatusBadRequest:
+		return grpcStatusInvalidArgument
+	case http.StatusUnauthorized:
+		return grpcStatusUnauthenticated
+	case http.StatusForbidden:
+		return grpcStatusPermissionDenied
+	case http.StatusNotFound:
+		return grpcStatusNotFound
+	case http.StatusNotImplemented:
+		return grpcStatusUnimplemented
+	case http.StatusTooManyRequests:
+		return grpcStatusResourceExhausted
+	case http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return grpcStatusUnavailable
+	case http.StatusInternalServerError:
+		return grpcStatusInternal
+	default:
+		return grpcStatusUnknown
+	}
+}
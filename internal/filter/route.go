@@ -0,0 +1,41 @@
+// Copyright © 2026 United Security Providers AG, Switzerland
+// SPDX-License-Identifier: Apache-2.0
+
+package filter
+
+import "coraza-waf/internal/config"
+
+// routeContext returns the names of the Envoy route, virtual host, upstream
+// cluster and filter chain serving the request. Names that are not configured
+// are empty. The upstream cluster is read from the matched route, the stream
+// info only knows it once the upstream request was sent.
+func (f *Filter) routeContext() config.RouteContext {
+	info := f.Callbacks.StreamInfo()
+	route := config.RouteContext{
+		Route:       info.GetRouteName(),
+		FilterChain: info.FilterChainName(),
+	}
+	if cluster, err := f.Callbacks.GetProperty("xds.cluster_name"); err == nil {
+		route.Cluster = cluster
+	}
+	if virtualHost, err := f.Callbacks.GetProperty("xds.virtual_host_name"); err == nil {
+		route.VirtualHost = virtualHost
+	}
+	return route
+}
+
+// routeVariables returns the TX variables of the non-empty names of the route context.
+func routeVariables(route config.RouteContext) [][2]string {
+	var variables [][2]string
+	for _, v := range [][2]string{
+		{"envoy_route", route.Route},
+		{"envoy_virtual_host", route.VirtualHost},
+		{"envoy_cluster", route.Cluster},
+		{"envoy_filter_chain", route.FilterChain},
+	} {
+		if v[1] != "" {
+			variables = append(variables, v)
+		}
+	}
+	return variables
+}
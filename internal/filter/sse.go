@@ -0,0 +1,133 @@
+// Copyright © 2026 United Security Providers AG, Switzerland
+// SPDX-License-Identifier: Apache-2.0
+
+package filter
+
+import (
+	"net/http"
+
+	"github.com/envoyproxy/envoy/contrib/golang/common/go/api"
+
+	"coraza-waf/internal/logging"
+	"coraza-waf/internal/sse"
+)
+
+const contentTypeEventStream = "text/event-stream"
+
+// sseErrorEvent is sent instead of a blocked event, the stream is reset right after it.
+const sseErrorEvent = "event: error\ndata: blocked by the web application firewall\n\n"
+
+// sseStream is the state of an event stream whose events are inspected one by one.
+type sseStream struct {
+	splitter sse.Splitter
+	events   int
+	// blocked is set when the error event was sent, the stream is reset
+	blocked bool
+}
+
+// encodeSSEData inspects every event completed by the data with the response
+// rules and passes it downstream right away. An incomplete event at the end is
+// passed with the rest of it. If an event is blocked it is replaced by an error event
+// and the stream is reset.
+func (f *Filter) encodeSSEData(logger logging.Logger, buffer api.BufferInstance, endStream bool) api.StatusType {
+	if f.sse.blocked {
+		// the response headers were sent, envoy resets the stream instead of sending the local reply
+		f.Callbacks.EncoderFilterCallbacks().SendLocalReply(http.StatusForbidden, "", map[string][]string{}, 0, "")
+		return api.LocalReply
+	}
+	events, err := f.sse.splitter.Write(buffer.Bytes())
+	if err != nil {
+		logger.Error("invalid event stream, ending the stream", "error", err.Error())
+		return f.blockSSE(buffer, nil)
+	}
+	if endStream {
+		if event := f.sse.splitter.Flush(); len(event) > 0 {
+			events = append(events, event)
+		}
+	}
+	forward, blocked := f.inspectSSEEvents(logger, events)
+	if blocked {
+		return f.blockSSE(buffer, forward)
+	}
+	if len(forward) != buffer.Len() {
+		if err := buffer.Set(forward); err != nil {
+			logger.Error("failed to write into internal buffer", "error", err)
+		}
+	}
+	if endStream {
+		err := f.validateResponseBody(logger)
+		if err != nil {
+			logger.Error("response validation failed", "error", err.Error())
+			return api.LocalReply
+		}
+	}
+	return api.Continue
+}
+
+// encodeSSETrailers inspects the incomplete event at the end of a stream ending
+// with trailers and passes it downstream before the trailers.
+func (f *Filter) encodeSSETrailers(logger logging.Logger) api.StatusType {
+	callbacks := f.Callbacks.EncoderFilterCallbacks()
+	if f.sse.blocked {
+		callbacks.SendLocalReply(http.StatusForbidden, "", map[string][]string{}, 0, "")
+		return api.LocalReply
+	}
+	event := f.sse.splitter.Flush()
+	if len(event) == 0 {
+		return api.Continue
+	}
+	forward, blocked := f.inspectSSEEvents(logger, [][]byte{event})
+	if blocked {
+		f.sse.blocked = true
+		callbacks.AddData(append(forward, sseErrorEvent...), true)
+		// the response headers were sent, envoy resets the stream instead of sending the local reply
+		callbacks.SendLocalReply(http.StatusForbidden, "", map[string][]string{}, 0, "")
+		return api.LocalReply
+	}
+	callbacks.AddData(forward, true)
+	return api.Continue
+}
+
+// inspectSSEEvents inspects the events with the response rules. It returns the
+// events passing the inspection, up to a blocked event, and whether one was blocked.
+func (f *Filter) inspectSSEEvents(logger logging.Logger, events [][]byte) ([]byte, bool) {
+	var forward []byte
+	for _, event := range events {
+		f.sse.events++
+		logger.Debug("Inspecting event", "event", f.sse.events, "size", len(event))
+		interruption, err := f.inspectResponseMessage(f.waf, event, contentTypeEventStream)
+		if err != nil {
+			logger.Error("Failed to inspect event, ending the stream", "event", f.sse.events, "error", err.Error())
+			return forward, true
+		}
+		if interruption != nil {
+			logger.Info(
+				"Event blocked",
+				"event", f.sse.events,
+				"ruleID", interruption.RuleID,
+				"action", interruption.Action,
+			)
+			return forward, true
+		}
+		forward = append(forward, event...)
+	}
+	return forward, false
+}
+
+// blockSSE passes the inspected events before the blocked one and the error event
+// downstream and resets the stream right after them. The response headers were
+// sent, so envoy resets the stream instead of sending a local reply, and drops the
+// data of the buffer with it. The events are injected into the rest of the filter
+// chain instead, which envoy only allows outside of the filter callbacks.
+func (f *Filter) blockSSE(buffer api.BufferInstance, forward []byte) api.StatusType {
+	f.sse.blocked = true
+	buffer.Reset()
+	data := append(forward, sseErrorEvent...)
+	callbacks := f.Callbacks.EncoderFilterCallbacks()
+	go func() {
+		defer callbacks.RecoverPanic()
+		callbacks.InjectData(data)
+		callbacks.SendLocalReply(http.StatusForbidden, "", map[string][]string{}, 0, "")
+	}()
+	return api.Running
+}
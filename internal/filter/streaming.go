@@ -0,0 +1,214 @@
+// Copyright © 2026 United Security Providers AG, Switzerland
+// SPDX-License-Identifier: Apache-2.0
+
+package filter
+
+import (
+	"errors"
+	"net/http"
+	"reflect"
+	"strings"
+
+	"github.com/corazawaf/coraza/v3/experimental/plugins/plugintypes"
+	"github.com/corazawaf/coraza/v3/types"
+	"github.com/envoyproxy/envoy/contrib/golang/common/go/api"
+
+	"coraza-waf/internal/config"
+	"coraza-waf/internal/logging"
+)
+
+// streamingOverlap is the number of bytes of a window that are inspected again
+// with the next window, so a payload split by the window boundary still matches.
+const streamingOverlap = 1024
+
+// contentTypeURLEncoded selects the URL-encoded body processor for a window. It can
+// parse a part of a URL-encoded body, the body processors of other bodies would fail.
+const contentTypeURLEncoded = "application/x-www-form-urlencoded"
+
+// streamingResetDetails is the response code detail of a request whose upstream request
+// was reset, available as %RESPONSE_CODE_DETAILS% in the access log
+const streamingResetDetails = "coraza_waf_upstream_reset"
+
+// requestStreaming is the state of a request body passed upstream while it is received.
+type requestStreaming struct {
+	interval int
+	// urlencoded is set if the windows are parsed by the URL-encoded body processor, otherwise they are inspected raw
+	urlencoded bool
+	// window holds the data received since the last inspection and the overlap of the previous window
+	window []byte
+	// received is the number of bytes received since the last inspection
+	received int
+	windows  int
+	// total is the number of bytes of the body received so far, limited to limit
+	total int64
+	// limit is the SecRequestBodyLimit of the transaction of the request, 0 if it is unknown
+	limit int64
+	// reject is set if a body exceeding the limit is rejected, otherwise the data beyond it is not inspected
+	reject bool
+}
+
+// newRequestStreaming starts streaming the body of the request. The body is limited
+// by the SecRequestBodyLimit of the transaction of the request, including a
+// ctl:requestBodyLimit of its request header rules, like a buffered body is.
+func (f *Filter) newRequestStreaming(logger logging.Logger, interval int, contentType string) *requestStreaming {
+	limit, reject, ok := requestBodyLimit(f.tx)
+	if !ok {
+		logger.Warn("SecRequestBodyLimit of the transaction is unknown, the streamed request body is not limited")
+	}
+	// the windows evaluate the request header rules again, their matches were logged with the request
+	config.MarkRequestHeadersLogged(f.tx.ID())
+	return &requestStreaming{
+		interval:   interval,
+		urlencoded: isURLEncodedContentType(contentType),
+		limit:      limit,
+		reject:     reject,
+	}
+}
+
+// requestBodyLimit returns the request body limit of the transaction and whether a
+// body exceeding it is rejected. Coraza does not expose the limit of a transaction,
+// it is read from the exported fields of its transaction.
+func requestBodyLimit(tx types.Transaction) (int64, bool, bool) {
+	value := reflect.ValueOf(tx)
+	if value.Kind() != reflect.Pointer || value.IsNil() || value.Elem().Kind() != reflect.Struct {
+		return 0, false, false
+	}
+	limit := value.Elem().FieldByName("RequestBodyLimit")
+	waf := value.Elem().FieldByName("WAF")
+	if !limit.CanInt() || waf.Kind() != reflect.Pointer || waf.IsNil() {
+		return 0, false, false
+	}
+	action := waf.Elem().FieldByName("RequestBodyLimitAction")
+	if !action.CanInt() {
+		return 0, false, false
+	}
+	return limit.Int(), types.BodyLimitAction(action.Int()) == types.BodyLimitActionReject, true
+}
+
+// isURLEncodedContentType reports whether a content type selects the URL-encoded body processor.
+func isURLEncodedContentType(contentType string) bool {
+	mediaType, _, _ := strings.Cut(contentType, ";")
+	return strings.EqualFold(strings.TrimSpace(mediaType), contentTypeURLEncoded)
+}
+
+// decodeStreamingData passes the data upstream and inspects it in windows. Every
+// time the interval is reached the received data is inspected on its own, the rest
+// at the end of the request. The body is not written to the transaction of the
+// request, so neither the filter nor Coraza buffers more than a window. A match
+// after data was passed upstream resets the upstream request. A body exceeding the
+// request body limit is rejected, or with SecRequestBodyLimitAction ProcessPartial
+// passed without inspecting the data beyond the limit.
+func (f *Filter) decodeStreamingData(logger logging.Logger, buffer api.BufferInstance, endStream bool) api.StatusType {
+	logger.Debug("Processing incoming request data", "size", buffer.Len())
+	data := buffer.Bytes()
+	if f.streaming.limit > 0 && f.streaming.total+int64(len(data)) > f.streaming.limit {
+		if f.streaming.reject {
+			logger.Info("Request body exceeds SecRequestBodyLimit", "limit", f.streaming.limit)
+			f.handleInterruption(logger, PhaseRequestBody, &types.Interruption{Status: http.StatusRequestEntityTooLarge, Action: "deny"})
+			return api.LocalReply
+		}
+		data = data[:f.streaming.limit-f.streaming.total]
+	}
+	if len(data) > 0 {
+		f.streaming.window = append(f.streaming.window, data...)
+		f.streaming.received += len(data)
+		f.streaming.total += int64(len(data))
+	}
+	if f.streaming.received >= f.streaming.interval || endStream {
+		if err := f.inspectStreamingWindow(logger); err != nil {
+			logger.Error("request validation failed", "error", err.Error())
+			return api.LocalReply
+		}
+	}
+	if endStream {
+		f.streaming.window = nil
+		// the body was inspected in windows, the request itself is inspected without a body
+		err := f.validateRequestBody(logger)
+		if err != nil {
+			logger.Error("request validation failed", "error", err.Error())
+			return api.LocalReply
+		}
+	}
+	return api.Continue
+}
+
+// inspectStreamingWindow inspects the data received since the last inspection, if any,
+// and keeps the overlap for the next window. URL-encoded windows are available as
+// REQUEST_BODY and ARGS_POST, the windows of other bodies only as REQUEST_BODY.
+func (f *Filter) inspectStreamingWindow(logger logging.Logger) error {
+	if f.streaming.received == 0 {
+		return nil
+	}
+	f.streaming.windows++
+	logger.Debug("Inspecting request body window", "window", f.streaming.windows, "size", len(f.streaming.window))
+	interruption, err := f.inspectWindow(f.streaming.window)
+	if err != nil {
+		logger.Error("Failed to inspect request body window", "window", f.streaming.windows, "error", err.Error())
+		f.Callbacks.DecoderFilterCallbacks().SendLocalReply(http.StatusInternalServerError, "", map[string][]string{}, 0, "")
+		return errors.New("failed to inspect request body window")
+	}
+	if interruption != nil {
+		// the request was partly passed upstream, envoy resets the upstream request with the reply
+		logger.Debug("Request body window interrupted, resetting the upstream request", "window", f.streaming.windows)
+		f.handleInterruption(logger, PhaseRequestBody, interruption)
+		return errors.New("found interruption")
+	}
+	overlap := min(streamingOverlap, len(f.streaming.window))
+	f.streaming.window = append(f.streaming.window[:0], f.streaming.window[len(f.streaming.window)-overlap:]...)
+	f.streaming.received = 0
+	return nil
+}
+
+// inspectWindow evaluates the request body rules against a window in a transaction
+// of its own. Coraza evaluates the body rules of a transaction only after its request
+// header rules, their matches are not logged again, see config.MarkRequestHeadersLogged.
+// URL-encoded windows are parsed by the URL-encoded body processor. Other windows are
+// set as REQUEST_BODY and the body processor selected by the content type or by a ctl
+// action of the request header rules is disabled, it can not parse a part of a body
+// and would fail with REQBODY_ERROR.
+func (f *Filter) inspectWindow(window []byte) (*types.Interruption, error) {
+	contentType := ""
+	if f.streaming.urlencoded {
+		contentType = contentTypeURLEncoded
+	}
+	tx, interruption := f.newMessageTransaction(f.waf, contentType)
+	defer closeWindowTransaction(tx)
+	if interruption != nil {
+		return interruption, nil
+	}
+	if !f.streaming.urlencoded {
+		state, ok := tx.(plugintypes.TransactionState)
+		if !ok {
+			return nil, errors.New("transaction does not expose its variables")
+		}
+		processor, processorOk := state.Variables().ReqbodyProcessor().(settableVariable)
+		requestBody, requestBodyOk := state.Variables().RequestBody().(settableVariable)
+		if !processorOk || !requestBodyOk {
+			return nil, errors.New("REQBODY_PROCESSOR and REQUEST_BODY of the transaction can not be set")
+		}
+		processor.Set("")
+		requestBody.Set(string(window))
+	}
+	interruption, _, err := tx.WriteRequestBody(window)
+	if err != nil || interruption != nil {
+		return interruption, err
+	}
+	return tx.ProcessRequestBody()
+}
+
+// settableVariable is a single value variable of a transaction, e.g. REQUEST_BODY.
+type settableVariable interface {
+	Set(value string)
+}
+
+// closeWindowTransaction closes the transaction of a window. It is only logged if
+// a request body rule matched, the transaction of the request is logged anyway.
+func closeWindowTransaction(tx types.Transaction) {
+	for _, rule := range tx.MatchedRules() {
+		if rule.Rule().Phase() == types.PhaseRequestBody {
+			tx.ProcessLogging()
+			break
+		}
+	}
+	_ = tx.Close()
+}
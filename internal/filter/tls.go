@@ -0,0 +1,90 @@
+// Copyright © 2026 United Security Providers AG, Switzerland
+// SPDX-License-Identifier: Apache-2.0
+
+package filter
+
+import (
+	"strings"
+
+	"github.com/corazawaf/coraza/v3/experimental/plugins/plugintypes"
+	"github.com/corazawaf/coraza/v3/types"
+
+	"coraza-waf/internal/logging"
+)
+
+// tlsFilterStates maps the TX variables to the filter state objects they are read from.
+// The fingerprints of the client hello are not part of the SSL connection, the objects
+// have to be set with the set_filter_state filter, e.g. from %TLS_JA3_FINGERPRINT%.
+var tlsFilterStates = []struct {
+	variable string
+	key      string
+}{
+	{"tls_ja3", "coraza.tls_ja3"},
+	{"tls_ja4", "coraza.tls_ja4"},
+}
+
+// tlsVariables returns the TX variables describing the TLS connection of the
+// request, nothing for a connection without TLS. They are read from the downstream
+// SSL connection, a certificate can have several SANs and every one is a value of
+// its variable. If the SNI differs from the server name of the Host header,
+// tls_sni_mismatch is set to 1 (domain fronting).
+func (f *Filter) tlsVariables(logger logging.Logger, server string) [][2]string {
+	ssl := f.Callbacks.StreamInfo().DownstreamSslConnection()
+	if ssl == nil || ssl.TlsVersion() == "" {
+		return nil
+	}
+	variables := [][2]string{{"tls_version", ssl.TlsVersion()}}
+	if cipher := ssl.CiphersuiteString(); cipher != "" {
+		variables = append(variables, [2]string{"tls_cipher", cipher})
+	}
+	sni, _ := f.Callbacks.GetProperty("connection.requested_server_name")
+	if sni != "" {
+		variables = append(variables, [2]string{"tls_sni", sni})
+	}
+	// like the connection.mtls attribute, the client presented a certificate which was validated
+	if ssl.PeerCertificatePresented() && ssl.PeerCertificateValidated() {
+		variables = append(variables, [2]string{"tls_mtls", "true"})
+	}
+	if subject := ssl.SubjectPeerCertificate(); subject != "" {
+		variables = append(variables, [2]string{"tls_peer_subject", subject})
+	}
+	for _, san := range ssl.DnsSansPeerCertificate() {
+		variables = append(variables, [2]string{"tls_peer_dns_san", san})
+	}
+	for _, san := range ssl.UriSanPeerCertificate() {
+		variables = append(variables, [2]string{"tls_peer_uri_san", san})
+	}
+	filterState := f.Callbacks.StreamInfo().FilterState()
+	for _, s := range tlsFilterStates {
+		// a formatter without value sets the object to -
+		if value := filterState.GetString(s.key); value != "" && value != "-" {
+			variables = append(variables, [2]string{s.variable, value})
+		}
+	}
+	if sni != "" && !strings.EqualFold(strings.TrimSuffix(sni, "."), strings.TrimSuffix(server, ".")) {
+		logger.Debug("Host does not match the SNI of the connection", "sni", sni, "server", server)
+		variables = append(variables, [2]string{"tls_sni_mismatch", "1"})
+	}
+	return variables
+}
+
+// setTxVariables sets the variables in the TX collection of the transaction. The
+// values of a variable listed several times are set together, e.g. the SANs of a
+// certificate.
+func setTxVariables(tx types.Transaction, variables [][2]string) {
+	state, ok := tx.(plugintypes.TransactionState)
+	if !ok {
+		return
+	}
+	var names []string
+	values := make(map[string][]string, len(variables))
+	for _, variable := range variables {
+		if _, ok := values[variable[0]]; !ok {
+			names = append(names, variable[0])
+		}
+		values[variable[0]] = append(values[variable[0]], variable[1])
+	}
+	for _, name := range names {
+		state.Variables().TX().Set(name, values[name])
+	}
+}
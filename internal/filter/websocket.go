@@ -0,0 +1,157 @@
+// Copyright © 2026 United Security Providers AG, Switzerland
+// SPDX-License-Identifier: Apache-2.0
+
+package filter
+
+import (
+	"errors"
+
+	"github.com/corazawaf/coraza/v3"
+	"github.com/corazawaf/coraza/v3/types"
+	"github.com/envoyproxy/envoy/contrib/golang/common/go/api"
+
+	"coraza-waf/internal/logging"
+	"coraza-waf/internal/websocket"
+)
+
+// websocketCloseReason is the reason of the close frames sent by the filter.
+const websocketCloseReason = "blocked by the web application firewall"
+
+// websocketStream is the state of a WebSocket connection whose messages are inspected.
+type websocketStream struct {
+	waf    coraza.WAF
+	client websocketDirection
+	server websocketDirection
+}
+
+// websocketDirection is the state of the messages sent by the client or by the server.
+type websocketDirection struct {
+	reader *websocket.Reader
+	// closed is set when the filter sent a close frame, further data is dropped
+	closed   bool
+	messages int
+}
+
+// newWebsocketStream starts the inspection of a WebSocket connection with the
+// WAF of the websocket directive, if the configuration sets one.
+func (f *Filter) newWebsocketStream(logger logging.Logger, extensions string) *websocketStream {
+	if f.Config.WebsocketDirective == "" {
+		return nil
+	}
+	waf := f.Config.Waf(f.Config.WebsocketDirective)
+	if waf == nil {
+		logger.Error("no WAF for the websocket directive, the messages are not inspected", "waf", f.Config.WebsocketDirective)
+		return nil
+	}
+	deflate := websocket.ParseExtensions(extensions)
+	return &websocketStream{
+		waf:    waf,
+		client: websocketDirection{reader: websocket.NewReader(deflate.Enabled, deflate.ClientContextTakeover)},
+		server: websocketDirection{reader: websocket.NewReader(deflate.Enabled, deflate.ServerContextTakeover)},
+	}
+}
+
+// decodeWebsocketData inspects the messages sent by the client. Complete frames
+// are passed upstream after the messages they complete are inspected. If a message
+// is blocked the data is replaced by a close frame, so the server closes the connection,
+// and the client gets a close frame as well.
+func (f *Filter) decodeWebsocketData(logger logging.Logger, buffer api.BufferInstance) api.StatusType {
+	direction := &f.websocket.client
+	f.inspectWebsocketData(logger, buffer, direction, true, func(message websocket.Message) (*types.Interruption, error) {
+		return f.inspectRequestMessage(f.websocket.waf, message.Data, websocketContentType(message))
+	})
+	return api.Continue
+}
+
+// encodeWebsocketData inspects the messages sent by the server. If a message is
+// blocked the data is replaced by a close frame, so the client closes the connection.
+func (f *Filter) encodeWebsocketData(logger logging.Logger, buffer api.BufferInstance) api.StatusType {
+	direction := &f.websocket.server
+	f.inspectWebsocketData(logger, buffer, direction, false, func(message websocket.Message) (*types.Interruption, error) {
+		return f.inspectResponseMessage(f.websocket.waf, message.Data, websocketContentType(message))
+	})
+	return api.Continue
+}
+
+func (f *Filter) inspectWebsocketData(
+	logger logging.Logger,
+	buffer api.BufferInstance,
+	direction *websocketDirection,
+	fromClient bool,
+	inspect func(websocket.Message) (*types.Interruption, error),
+) {
+	if direction.closed {
+		buffer.Reset()
+		return
+	}
+	forward, messages, err := direction.reader.Write(buffer.Bytes())
+	if err != nil {
+		code := websocket.CloseProtocolError
+		if errors.Is(err, websocket.ErrMessageTooLarge) {
+			code = websocket.CloseMessageTooBig
+		}
+		logger.Error("invalid websocket data, closing the connection", "error", err.Error())
+		f.closeWebsocket(logger, buffer, direction, fromClient, code)
+		return
+	}
+	for _, message := range messages {
+		direction.messages++
+		logger.Debug("Inspecting websocket message", "message", direction.messages, "binary", message.Binary, "size", len(message.Data))
+		interruption, err := inspect(message)
+		if err != nil {
+			logger.Error("Failed to inspect websocket message, closing the connection", "message", direction.messages, "error", err.Error())
+			f.closeWebsocket(logger, buffer, direction, fromClient, websocket.ClosePolicyViolation)
+			return
+		}
+		if interruption != nil {
+			logger.Info(
+				"Websocket message blocked",
+				"message", direction.messages,
+				"ruleID", interruption.RuleID,
+				"action", interruption.Action,
+			)
+			f.closeWebsocket(logger, buffer, direction, fromClient, websocket.ClosePolicyViolation)
+			return
+		}
+	}
+	// an incomplete frame at the end is passed with the rest of it
+	if len(forward) != buffer.Len() {
+		if err := buffer.Set(forward); err != nil {
+			logger.Error("failed to write into internal buffer", "error", err)
+		}
+	}
+}
+
+// closeWebsocket replaces the data with a close frame. The peer answers the close
+// frame and closes the connection, the data following it is dropped. If the client
+// sent the data, it gets a close frame too and the data of the server is dropped,
+// as an endpoint must not send data after its close frame.
+func (f *Filter) closeWebsocket(logger logging.Logger, buffer api.BufferInstance, direction *websocketDirection, fromClient bool, code int) {
+	direction.closed = true
+	// frames sent by the client to the server are masked
+	if err := buffer.Set(websocket.CloseFrame(code, websocketCloseReason, fromClient)); err != nil {
+		logger.Error("failed to write into internal buffer", "error", err)
+	}
+	if fromClient && !f.websocket.server.closed {
+		f.websocket.server.closed = true
+		f.injectWebsocketClose(code)
+	}
+}
+
+// injectWebsocketClose sends a close frame downstream. Envoy injects data only
+// outside of the filter callbacks, so the frame is injected asynchronously.
+func (f *Filter) injectWebsocketClose(code int) {
+	frame := websocket.CloseFrame(code, websocketCloseReason, false)
+	callbacks := f.Callbacks.EncoderFilterCallbacks()
+	go func() {
+		defer callbacks.RecoverPanic()
+		callbacks.InjectData(frame)
+	}()
+}
+
+func websocketContentType(message websocket.Message) string {
+	if message.Binary {
+		return "application/octet-stream"
+	}
+	return "text/plain"
+}
@@ -0,0 +1,66 @@
+// Copyright © 2026 United Security Providers AG, Switzerland
+// SPDX-License-Identifier: Apache-2.0
+
+package grpcbody
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/dynamicpb"
+)
+
+// Descriptors decodes protobuf messages of the services in a FileDescriptorSet,
+// e.g. generated with protoc --include_imports --descriptor_set_out.
+type Descriptors struct {
+	files *protoregistry.Files
+}
+
+// LoadDescriptors reads a binary FileDescriptorSet.
+func LoadDescriptors(path string) (*Descriptors, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var set descriptorpb.FileDescriptorSet
+	if err := proto.Unmarshal(content, &set); err != nil {
+		return nil, fmt.Errorf("invalid FileDescriptorSet: %w", err)
+	}
+	files, err := protodesc.NewFiles(&set)
+	if err != nil {
+		return nil, fmt.Errorf("invalid FileDescriptorSet: %w", err)
+	}
+	return &Descriptors{files: files}, nil
+}
+
+// RequestToJSON decodes a request message of the method called by path, /package.Service/Method, to JSON.
+func (d *Descriptors) RequestToJSON(path string, payload []byte) ([]byte, error) {
+	service, method, ok := strings.Cut(strings.TrimPrefix(path, "/"), "/")
+	if !ok {
+		return nil, fmt.Errorf("invalid gRPC path %s", path)
+	}
+	descriptor, err := d.files.FindDescriptorByName(protoreflect.FullName(service))
+	if err != nil {
+		return nil, fmt.Errorf("unknown gRPC service %s: %w", service, err)
+	}
+	serviceDescriptor, ok := descriptor.(protoreflect.ServiceDescriptor)
+	if !ok {
+		return nil, fmt.Errorf("%s is not a gRPC service", service)
+	}
+	methodDescriptor := serviceDescriptor.Methods().ByName(protoreflect.Name(method))
+	if methodDescriptor == nil {
+		return nil, fmt.Errorf("unknown gRPC method %s of service %s", method, service)
+	}
+	message := dynamicpb.NewMessage(methodDescriptor.Input())
+	if err := proto.Unmarshal(payload, message); err != nil {
+		return nil, fmt.Errorf("invalid %s message: %w", methodDescriptor.Input().FullName(), err)
+	}
+	return protojson.Marshal(message)
+}
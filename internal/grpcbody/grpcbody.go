@@ -0,0 +1,91 @@
+// Copyright © 2026 United Security Providers AG, Switzerland
+// SPDX-License-Identifier: Apache-2.0
+
+// Package grpcbody splits gRPC request bodies into their length-prefixed
+// messages, see https://github.com/grpc/grpc/blob/master/doc/PROTOCOL-HTTP2.md
+package grpcbody
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// headerSize is the size of the compressed flag and the message length in front of every message.
+const headerSize = 5
+
+// MaxMessageSize is the default maximum receive message size of gRPC servers, larger messages are rejected.
+const MaxMessageSize = 4 << 20
+
+var ErrMessageTooLarge = fmt.Errorf("grpc message exceeds the maximum size of %d bytes", MaxMessageSize)
+
+// Message is a single message of a gRPC stream.
+type Message struct {
+	Compressed bool
+	Data       []byte
+}
+
+// Framer collects the data of a gRPC stream and splits it into messages.
+type Framer struct {
+	pending []byte
+}
+
+// Write appends data to the stream and returns the messages completed by it.
+// An incomplete message is kept until the rest of it is written.
+func (f *Framer) Write(data []byte) ([]Message, error) {
+	f.pending = append(f.pending, data...)
+	var messages []Message
+	for len(f.pending) >= headerSize {
+		length := binary.BigEndian.Uint32(f.pending[1:headerSize])
+		if length > MaxMessageSize {
+			return messages, ErrMessageTooLarge
+		}
+		end := headerSize + int(length)
+		if len(f.pending) < end {
+			break
+		}
+		messages = append(messages, Message{
+			Compressed: f.pending[0]&1 == 1,
+			Data:       bytes.Clone(f.pending[headerSize:end]),
+		})
+		f.pending = f.pending[end:]
+	}
+	return messages, nil
+}
+
+// Pending reports whether the stream ends in the middle of a message.
+func (f *Framer) Pending() bool {
+	return len(f.pending) > 0
+}
+
+// Payload returns the uncompressed message. encoding is the value of the
+// grpc-encoding header of the stream, only gzip is supported.
+func (m Message) Payload(encoding string) ([]byte, error) {
+	if !m.Compressed {
+		return m.Data, nil
+	}
+	switch encoding {
+	case "gzip":
+		reader, err := gzip.NewReader(bytes.NewReader(m.Data))
+		if err != nil {
+			return nil, fmt.Errorf("invalid gzip message: %w", err)
+		}
+		defer reader.Close()
+		// the limit protects against decompression bombs
+		payload, err := io.ReadAll(io.LimitReader(reader, MaxMessageSize+1))
+		if err != nil {
+			return nil, fmt.Errorf("invalid gzip message: %w", err)
+		}
+		if len(payload) > MaxMessageSize {
+			return nil, ErrMessageTooLarge
+		}
+		return payload, nil
+	case "", "identity":
+		return nil, errors.New("compressed message without grpc-encoding")
+	default:
+		return nil, fmt.Errorf("unsupported grpc-encoding %s", encoding)
+	}
+}
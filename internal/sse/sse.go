@@ -0,0 +1,79 @@
+// Copyright © 2026 United Security Providers AG, Switzerland
+// SPDX-License-Identifier: Apache-2.0
+
+// Package sse splits a Server-Sent Events stream into its events, see
+// https://html.spec.whatwg.org/multipage/server-sent-events.html#parsing-an-event-stream
+package sse
+
+import (
+	"bytes"
+	"fmt"
+)
+
+// MaxEventSize limits the size of a single event.
+const MaxEventSize = 4 << 20
+
+var ErrEventTooLarge = fmt.Errorf("event exceeds the maximum size of %d bytes", MaxEventSize)
+
+// Splitter collects the data of an event stream and splits it into events.
+type Splitter struct {
+	pending []byte
+}
+
+// Write appends data to the stream and returns the events completed by it,
+// each including the empty line ending it. An incomplete event is kept until
+// the rest of it is written.
+func (s *Splitter) Write(data []byte) ([][]byte, error) {
+	buffer := data
+	if len(s.pending) > 0 {
+		buffer = append(s.pending, data...)
+	}
+	var events [][]byte
+	for {
+		end := eventEnd(buffer)
+		if end < 0 {
+			break
+		}
+		events = append(events, bytes.Clone(buffer[:end]))
+		buffer = buffer[end:]
+	}
+	if len(buffer) > MaxEventSize {
+		return events, ErrEventTooLarge
+	}
+	s.pending = bytes.Clone(buffer)
+	return events, nil
+}
+
+// Flush returns the incomplete event at the end of the stream.
+func (s *Splitter) Flush() []byte {
+	event := s.pending
+	s.pending = nil
+	return event
+}
+
+// eventEnd returns the end of the first event in data, or -1 if it is incomplete.
+// An event ends with an empty line, lines end with CRLF, LF or CR.
+func eventEnd(data []byte) int {
+	lineStart := 0
+	for i := 0; i < len(data); i++ {
+		if data[i] != '\n' && data[i] != '\r' {
+			continue
+		}
+		end := i + 1
+		if data[i] == '\r' {
+			// a CR at the end of the data could be the start of a CRLF
+			if end == len(data) {
+				return -1
+			}
+			if data[end] == '\n' {
+				end++
+			}
+		}
+		if i == lineStart {
+			return end
+		}
+		lineStart = end
+		i = end - 1
+	}
+	return -1
+}
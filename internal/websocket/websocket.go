@@ -0,0 +1,265 @@
+// Copyright © 2026 United Security Providers AG, Switzerland
+// SPDX-License-Identifier: Apache-2.0
+
+// Package websocket parses the frames of a WebSocket connection into messages,
+// see https://datatracker.ietf.org/doc/html/rfc6455 and, for permessage-deflate,
+// https://datatracker.ietf.org/doc/html/rfc7692
+package websocket
+
+import (
+	"bytes"
+	"compress/flate"
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+const (
+	opContinuation = 0x0
+	opText         = 0x1
+	opBinary       = 0x2
+	opClose        = 0x8
+)
+
+// Close codes sent when the filter closes a connection.
+const (
+	ClosePolicyViolation = 1008
+	CloseMessageTooBig   = 1009
+	CloseProtocolError   = 1002
+)
+
+// MaxMessageSize limits the size of a message, also after decompression.
+const MaxMessageSize = 4 << 20
+
+// windowSize is the maximum LZ77 window of deflate.
+const windowSize = 32 << 10
+
+var (
+	ErrMessageTooLarge = fmt.Errorf("websocket message exceeds the maximum size of %d bytes", MaxMessageSize)
+	ErrProtocol        = errors.New("websocket protocol error")
+)
+
+// Message is a complete text or binary message.
+type Message struct {
+	Binary bool
+	Data   []byte
+}
+
+// Deflate is the permessage-deflate extension negotiated for a connection.
+type Deflate struct {
+	Enabled               bool
+	ClientContextTakeover bool
+	ServerContextTakeover bool
+}
+
+// ParseExtensions returns the permessage-deflate parameters of the
+// Sec-WebSocket-Extensions header of the upgrade response.
+func ParseExtensions(header string) Deflate {
+	for _, extension := range strings.Split(header, ",") {
+		params := strings.Split(extension, ";")
+		if strings.TrimSpace(params[0]) != "permessage-deflate" {
+			continue
+		}
+		deflate := Deflate{Enabled: true, ClientContextTakeover: true, ServerContextTakeover: true}
+		for _, param := range params[1:] {
+			name, _, _ := strings.Cut(strings.TrimSpace(param), "=")
+			switch name {
+			case "client_no_context_takeover":
+				deflate.ClientContextTakeover = false
+			case "server_no_context_takeover":
+				deflate.ServerContextTakeover = false
+			}
+		}
+		return deflate
+	}
+	return Deflate{}
+}
+
+// Reader parses the frames of one direction of a connection.
+type Reader struct {
+	deflate         bool
+	contextTakeover bool
+
+	// pending is the start of an incomplete frame
+	pending []byte
+	// message collects the fragments of the current message
+	message    []byte
+	inMessage  bool
+	binary     bool
+	compressed bool
+	// window is the end of the decompressed data, used as dictionary with context takeover
+	window []byte
+}
+
+// NewReader returns a reader for a direction using permessage-deflate if enabled,
+// contextTakeover tells whether the compression context is kept between messages.
+func NewReader(deflate bool, contextTakeover bool) *Reader {
+	return &Reader{deflate: deflate, contextTakeover: contextTakeover}
+}
+
+// Write parses data and returns the messages it completes. forward are the
+// complete frames of the pending and the new data, an incomplete frame at the
+// end is kept until the rest of it is written.
+func (r *Reader) Write(data []byte) (forward []byte, messages []Message, err error) {
+	buffer := data
+	if len(r.pending) > 0 {
+		buffer = append(r.pending, data...)
+	}
+	offset := 0
+	for {
+		frame, n, err := parseFrame(buffer[offset:])
+		if err != nil {
+			return nil, messages, err
+		}
+		if n == 0 {
+			break
+		}
+		offset += n
+		message, complete, err := r.add(frame)
+		if err != nil {
+			return nil, messages, err
+		}
+		if complete {
+			messages = append(messages, message)
+		}
+	}
+	r.pending = bytes.Clone(buffer[offset:])
+	return buffer[:offset], messages, nil
+}
+
+type frame struct {
+	fin     bool
+	rsv1    bool
+	opcode  byte
+	payload []byte
+}
+
+// parseFrame parses the frame at the start of b, n is 0 if the frame is incomplete.
+func parseFrame(b []byte) (frame, int, error) {
+	if len(b) < 2 {
+		return frame{}, 0, nil
+	}
+	f := frame{fin: b[0]&0x80 != 0, rsv1: b[0]&0x40 != 0, opcode: b[0] & 0x0f}
+	masked := b[1]&0x80 != 0
+	length := uint64(b[1] & 0x7f)
+	offset := 2
+	switch length {
+	case 126:
+		if len(b) < offset+2 {
+			return frame{}, 0, nil
+		}
+		length = uint64(binary.BigEndian.Uint16(b[offset:]))
+		offset += 2
+	case 127:
+		if len(b) < offset+8 {
+			return frame{}, 0, nil
+		}
+		length = binary.BigEndian.Uint64(b[offset:])
+		offset += 8
+	}
+	if length > MaxMessageSize {
+		return frame{}, 0, ErrMessageTooLarge
+	}
+	var mask []byte
+	if masked {
+		if len(b) < offset+4 {
+			return frame{}, 0, nil
+		}
+		mask = b[offset : offset+4]
+		offset += 4
+	}
+	end := offset + int(length)
+	if len(b) < end {
+		return frame{}, 0, nil
+	}
+	f.payload = bytes.Clone(b[offset:end])
+	if masked {
+		for i := range f.payload {
+			f.payload[i] ^= mask[i%4]
+		}
+	}
+	return f, end, nil
+}
+
+// add adds a frame to the current message and returns the message if the frame completes it.
+// Control frames can be sent between the fragments of a message and are not inspected.
+func (r *Reader) add(f frame) (Message, bool, error) {
+	switch f.opcode {
+	case opText, opBinary:
+		if r.inMessage {
+			return Message{}, false, fmt.Errorf("%w: new message before the end of a fragmented message", ErrProtocol)
+		}
+		r.inMessage = true
+		r.binary = f.opcode == opBinary
+		r.compressed = r.deflate && f.rsv1
+		r.message = r.message[:0]
+	case opContinuation:
+		if !r.inMessage {
+			return Message{}, false, fmt.Errorf("%w: continuation frame without a message", ErrProtocol)
+		}
+	default:
+		return Message{}, false, nil
+	}
+	if len(r.message)+len(f.payload) > MaxMessageSize {
+		return Message{}, false, ErrMessageTooLarge
+	}
+	r.message = append(r.message, f.payload...)
+	if !f.fin {
+		return Message{}, false, nil
+	}
+	r.inMessage = false
+	data := bytes.Clone(r.message)
+	if r.compressed {
+		var err error
+		if data, err = r.inflate(data); err != nil {
+			return Message{}, false, err
+		}
+	}
+	return Message{Binary: r.binary, Data: data}, true, nil
+}
+
+// inflate decompresses a permessage-deflate message. With context takeover the
+// message can reference the data of previous messages, which is provided as dictionary.
+func (r *Reader) inflate(data []byte) ([]byte, error) {
+	// the sender removes the empty block at the end of the message
+	data = append(data, 0x00, 0x00, 0xff, 0xff)
+	reader := flate.NewReaderDict(bytes.NewReader(data), r.window)
+	defer reader.Close()
+	payload, err := io.ReadAll(io.LimitReader(reader, MaxMessageSize+1))
+	// the message does not end with a final block
+	if err != nil && !errors.Is(err, io.ErrUnexpectedEOF) {
+		return nil, fmt.Errorf("invalid compressed websocket message: %w", err)
+	}
+	if len(payload) > MaxMessageSize {
+		return nil, ErrMessageTooLarge
+	}
+	if r.contextTakeover {
+		r.window = append(r.window, payload...)
+		if len(r.window) > windowSize {
+			r.window = bytes.Clone(r.window[len(r.window)-windowSize:])
+		}
+	}
+	return payload, nil
+}
+
+// CloseFrame returns a close frame with the code and reason. Frames sent by
+// the client must be masked.
+func CloseFrame(code int, reason string, masked bool) []byte {
+	payload := binary.BigEndian.AppendUint16(nil, uint16(code))
+	// the payload of a control frame is limited to 125 bytes
+	payload = append(payload, reason[:min(len(reason), 123)]...)
+	frame := []byte{0x80 | opClose, byte(len(payload))}
+	if masked {
+		mask := make([]byte, 4)
+		_, _ = rand.Read(mask)
+		frame[1] |= 0x80
+		frame = append(frame, mask...)
+		for i := range payload {
+			payload[i] ^= mask[i%4]
+		}
+	}
+	return append(frame, payload...)
+}
@@ -125,3 +125,34 @@ func TestE2ESSEPERuleDisableResponseBodyInspection(t *testing.T) {
 		require.True(t, strings.HasPrefix(r.Data, "Server time:"), "expected SSE data to start with 'Server time:'")
 	}
 }
+
+func TestE2ESSEEventInspectionKeepsTiming(t *testing.T) {
+	results, err := readSSEEvents("sse-events.example.com", envoyEndpoint+"/events/5", 5, 10*time.Second)
+	require.NoError(t, err)
+	require.Equal(t, 5, len(results))
+
+	err = verifySSETiming(results, 200*time.Millisecond)
+	require.NoError(t, err)
+}
+
+func TestE2ESSEEventInspectionBlocksEvent(t *testing.T) {
+	// the stream is reset right after the error event, the read error is expected
+	results, _ := readSSEEvents("sse-events.example.com", envoyEndpoint+"/events/body/2/allowed-event/2/forbidden-event", 4, 10*time.Second)
+	require.Equal(t, 3, len(results))
+	require.Equal(t, "allowed-event", results[0].Data)
+	require.Equal(t, "allowed-event", results[1].Data)
+	require.Equal(t, "blocked by the web application firewall", results[2].Data)
+
+	err := verifySSETiming(results[:2], 200*time.Millisecond)
+	require.NoError(t, err)
+}
+
+func TestE2ESSEEventInspectionResetsAfterErrorEvent(t *testing.T) {
+	start := time.Now()
+	// the next event follows a second later, the stream must not stay open until then
+	results, err := readSSEEvents("sse-events.example.com", envoyEndpoint+"/events/body/1/allowed-event/3/forbidden-event", 4, 10*time.Second)
+	require.Error(t, err)
+	require.Equal(t, 2, len(results))
+	require.Equal(t, "blocked by the web application firewall", results[1].Data)
+	require.Less(t, time.Since(start)-results[1].Elapsed, 500*time.Millisecond)
+}
@@ -4,9 +4,15 @@
 package e2e
 
 import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
 	"context"
+	"encoding/binary"
+	"encoding/json"
 	"fmt"
 	"io"
+	"net"
 	"net/http"
 	"os"
 	"path/filepath"
@@ -23,8 +29,10 @@ import (
 )
 
 var (
-	envoyEndpoint string
-	backendLogs   *LogCollector
+	envoyEndpoint    string
+	envoyTLSEndpoint string
+	envoyContainer   testcontainers.Container
+	backendLogs      *LogCollector
 )
 
 func absPath(components ...string) (string, error) {
@@ -123,6 +131,38 @@ func setupEnvironment(enableLogs bool) (string, func(), error) {
 		return "", nil, fmt.Errorf("get custom_rules path: %w", err)
 	}
 
+	reloadRulesPath, err := absPath(".", "reload_rules")
+	if err != nil {
+		httpbin.Terminate(ctx)
+		sseServer.Terminate(ctx)
+		net.Remove(ctx)
+		return "", nil, fmt.Errorf("get reload_rules path: %w", err)
+	}
+
+	dataFilesPath, err := absPath(".", "data_files")
+	if err != nil {
+		httpbin.Terminate(ctx)
+		sseServer.Terminate(ctx)
+		net.Remove(ctx)
+		return "", nil, fmt.Errorf("get data_files path: %w", err)
+	}
+
+	grpcPath, err := absPath(".", "grpc")
+	if err != nil {
+		httpbin.Terminate(ctx)
+		sseServer.Terminate(ctx)
+		net.Remove(ctx)
+		return "", nil, fmt.Errorf("get grpc path: %w", err)
+	}
+
+	tlsPath, err := absPath(".", "tls")
+	if err != nil {
+		httpbin.Terminate(ctx)
+		sseServer.Terminate(ctx)
+		net.Remove(ctx)
+		return "", nil, fmt.Errorf("get tls path: %w", err)
+	}
+
 	envoy, err := testcontainers.Run(ctx,
 		"coraza-waf-envoy",
 		testcontainers.WithCmd(
@@ -150,11 +190,32 @@ func setupEnvironment(enableLogs bool) (string, func(), error) {
 				ContainerFilePath: "/etc/envoy/custom_rules",
 				FileMode:          0o755,
 			},
+			testcontainers.ContainerFile{
+				HostFilePath:      reloadRulesPath,
+				ContainerFilePath: "/etc/envoy/reload_rules",
+				FileMode:          0o755,
+			},
+			testcontainers.ContainerFile{
+				HostFilePath:      dataFilesPath,
+				ContainerFilePath: "/etc/envoy/data_files",
+				FileMode:          0o755,
+			},
+			testcontainers.ContainerFile{
+				HostFilePath:      grpcPath,
+				ContainerFilePath: "/etc/envoy/grpc",
+				FileMode:          0o755,
+			},
+			testcontainers.ContainerFile{
+				HostFilePath:      tlsPath,
+				ContainerFilePath: "/etc/envoy/tls",
+				FileMode:          0o755,
+			},
 		),
-		testcontainers.WithExposedPorts("8081/tcp"),
+		testcontainers.WithExposedPorts("8081/tcp", "8443/tcp"),
 		network.WithNetwork([]string{"envoy"}, net),
 		testcontainers.WithWaitStrategy(
 			wait.ForListeningPort("8081/tcp"),
+			wait.ForListeningPort("8443/tcp"),
 			wait.ForLog("all dependencies initialized. starting workers"),
 			//wait.ForHTTP("/ready").WithPort("9999"),
 		),
@@ -179,6 +240,15 @@ func setupEnvironment(enableLogs bool) (string, func(), error) {
 		return "", nil, fmt.Errorf("coult not get envoy endpoint: %w", err)
 	}
 
+	tlsEndpoint, err := envoy.PortEndpoint(ctx, "8443", "https")
+	if err != nil {
+		httpbin.Terminate(ctx)
+		sseServer.Terminate(ctx)
+		envoy.Terminate(ctx)
+		net.Remove(ctx)
+		return "", nil, fmt.Errorf("coult not get envoy TLS endpoint: %w", err)
+	}
+
 	cleanup := func() {
 		httpbin.Terminate(ctx)
 		sseServer.Terminate(ctx)
@@ -187,6 +257,8 @@ func setupEnvironment(enableLogs bool) (string, func(), error) {
 	}
 
 	backendLogs = httpbinLogs
+	envoyContainer = envoy
+	envoyTLSEndpoint = tlsEndpoint
 
 	return endpoint, cleanup, nil
 }
@@ -357,12 +429,393 @@ func TestE2ETruePositiveResponseBodyOutsideLimitProcessPartial(t *testing.T) {
 	checkInLogs(t, http.StatusOK, http.MethodPost, "/post")
 }
 
+// Testing the decompression of compressed response bodies, httpbin echoes the request headers compressed
+func TestE2ECompressedResponseBodyTrueNegative(t *testing.T) {
+	backendLogs.Reset()
+	checkRequest(t, "foo.example.com", envoyEndpoint+"/gzip", http.MethodGet, http.StatusOK, false, "", "Accept-Encoding", "gzip")
+	checkInLogs(t, http.StatusOK, http.MethodGet, "/gzip")
+}
+
+func TestE2ECompressedResponseBodyTruePositiveGzip(t *testing.T) {
+	backendLogs.Reset()
+	checkRequest(t, "foo.example.com", envoyEndpoint+"/gzip", http.MethodGet, http.StatusForbidden, true, "", "Accept-Encoding", "gzip", "X-Echo", "responsebodycode")
+	checkInLogs(t, http.StatusOK, http.MethodGet, "/gzip")
+}
+
+func TestE2ECompressedResponseBodyTruePositiveDeflate(t *testing.T) {
+	backendLogs.Reset()
+	checkRequest(t, "foo.example.com", envoyEndpoint+"/deflate", http.MethodGet, http.StatusForbidden, true, "", "Accept-Encoding", "deflate", "X-Echo", "responsebodycode")
+	checkInLogs(t, http.StatusOK, http.MethodGet, "/deflate")
+}
+
+// the zstd and br responses are compressed by envoy, see envoy.yaml
+func TestE2ECompressedResponseBodyTruePositiveZstd(t *testing.T) {
+	backendLogs.Reset()
+	checkRequest(t, "foo.example.com", envoyEndpoint+"/anything", http.MethodGet, http.StatusForbidden, true, "", "Accept-Encoding", "zstd", "X-Echo", "responsebodycode")
+	checkInLogs(t, http.StatusOK, http.MethodGet, "/anything")
+}
+
+func TestE2ECompressedResponseBodyTruePositiveBrotli(t *testing.T) {
+	backendLogs.Reset()
+	checkRequest(t, "foo.example.com", envoyEndpoint+"/anything", http.MethodGet, http.StatusForbidden, true, "", "Accept-Encoding", "br", "X-Echo", "responsebodycode")
+	checkInLogs(t, http.StatusOK, http.MethodGet, "/anything")
+}
+
 func TestE2EResponseBodyLimitReject(t *testing.T) {
 	backendLogs.Reset()
 	checkRequest(t, "baz.example.com", envoyEndpoint+"/bytes/80", http.MethodGet, http.StatusInternalServerError, true, "")
 	checkInLogs(t, http.StatusOK, http.MethodGet, "/bytes/80")
 }
 
+func checkRedirect(t *testing.T, host string, url string, method string, data string, expectedStatus int, expectedLocation string) {
+	// do not follow the redirect, the target is not reachable from the tests
+	client := http.Client{
+		CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+	req, err := http.NewRequest(method, url, strings.NewReader(data))
+	require.NoError(t, err)
+	req.Host = host
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	resp, err := client.Do(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+
+	require.Equal(t, expectedStatus, resp.StatusCode)
+	require.Equal(t, expectedLocation, resp.Header.Get("Location"))
+	require.Empty(t, string(body))
+}
+
+func TestE2ERedirectRequestHeaderPhase(t *testing.T) {
+	backendLogs.Reset()
+	checkRedirect(t, "foo.example.com", envoyEndpoint+"/redirect", http.MethodGet, "", http.StatusFound, "https://example.com/blocked")
+	checkNotInLogs(t, http.MethodGet, "/redirect")
+}
+
+func TestE2ERedirectKeepsRuleStatus(t *testing.T) {
+	backendLogs.Reset()
+	checkRedirect(t, "foo.example.com", envoyEndpoint+"/redirect-permanent", http.MethodGet, "", http.StatusMovedPermanently, "https://example.com/moved")
+	checkNotInLogs(t, http.MethodGet, "/redirect-permanent")
+}
+
+func TestE2ERedirectRequestBodyPhase(t *testing.T) {
+	backendLogs.Reset()
+	checkRedirect(t, "foo.example.com", envoyEndpoint+"/post", http.MethodPost, "redirectpayload", http.StatusSeeOther, "https://example.com/blocked")
+	checkNotInLogs(t, http.MethodPost, "/post")
+}
+
+func TestE2ERedirectResponseBodyPhase(t *testing.T) {
+	backendLogs.Reset()
+	checkRedirect(t, "foo.example.com", envoyEndpoint+"/post", http.MethodPost, "responseredirect", http.StatusSeeOther, "https://example.com/blocked")
+	checkInLogs(t, http.StatusOK, http.MethodPost, "/post")
+}
+
+func TestE2EDropSendsNoBody(t *testing.T) {
+	backendLogs.Reset()
+	// a browser would get a HTML page for a deny, a drop never sends a body
+	checkRequest(t, "foo.example.com", envoyEndpoint+"/drop", http.MethodGet, http.StatusForbidden, true, "", "Accept", "text/html")
+	checkNotInLogs(t, http.MethodGet, "/drop")
+}
+
+func TestE2EDropClosesConnection(t *testing.T) {
+	backendLogs.Reset()
+	conn, err := net.Dial("tcp", strings.TrimPrefix(envoyEndpoint, "http://"))
+	require.NoError(t, err)
+	defer conn.Close()
+	require.NoError(t, conn.SetDeadline(time.Now().Add(5*time.Second)))
+	_, err = fmt.Fprint(conn, "GET /drop HTTP/1.1\r\nHost: foo.example.com\r\nConnection: keep-alive\r\n\r\n")
+	require.NoError(t, err)
+
+	reader := bufio.NewReader(conn)
+	resp, err := http.ReadResponse(reader, nil)
+	require.NoError(t, err)
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	require.Equal(t, http.StatusForbidden, resp.StatusCode)
+	require.Empty(t, body)
+	require.True(t, resp.Close)
+	// the connection is closed by envoy instead of being kept alive for the next request
+	_, err = reader.ReadByte()
+	require.ErrorIs(t, err, io.EOF)
+	checkNotInLogs(t, http.MethodGet, "/drop")
+}
+
+// Testing requests ending with trailers, the request body is processed when the trailers arrive
+func sendWithTrailer(t *testing.T, url string, data string, trailer string, additionalHeaders ...string) int {
+	req, err := http.NewRequest(http.MethodPost, url, io.NopCloser(strings.NewReader(data)))
+	require.NoError(t, err)
+	req.Host = "foo.example.com"
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	for i := 0; i < len(additionalHeaders); i += 2 {
+		req.Header.Set(additionalHeaders[i], additionalHeaders[i+1])
+	}
+	// a chunked body is required to send trailers
+	req.ContentLength = -1
+	req.Trailer = http.Header{"X-Trailer-Check": {trailer}}
+
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	_, err = io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	return resp.StatusCode
+}
+
+func TestE2ETrailersTrueNegative(t *testing.T) {
+	backendLogs.Reset()
+	require.Equal(t, http.StatusOK, sendWithTrailer(t, envoyEndpoint+"/post", "This is a valid payload", "harmless"))
+	checkInLogs(t, http.StatusOK, http.MethodPost, "/post")
+}
+
+func TestE2ETrailersTruePositiveTrailer(t *testing.T) {
+	backendLogs.Reset()
+	require.Equal(t, http.StatusForbidden, sendWithTrailer(t, envoyEndpoint+"/post", "This is a valid payload", "trailerpayload"))
+	checkNotInLogs(t, http.MethodPost, "/post")
+}
+
+func TestE2ETrailersTruePositiveRequestBody(t *testing.T) {
+	backendLogs.Reset()
+	require.Equal(t, http.StatusForbidden, sendWithTrailer(t, envoyEndpoint+"/post", "trailedpayload", "harmless"))
+	checkNotInLogs(t, http.MethodPost, "/post")
+}
+
+// Testing the resolution of the client address, the /client-ip route trusts every peer as proxy
+func TestE2EClientIPFromTrustedProxy(t *testing.T) {
+	backendLogs.Reset()
+	checkRequest(t, "foo.example.com", envoyEndpoint+"/client-ip", http.MethodGet, http.StatusForbidden, true, "", "X-Forwarded-For", "203.0.113.7")
+	checkNotInLogs(t, http.MethodGet, "/anything")
+}
+
+func TestE2EClientIPHops(t *testing.T) {
+	// the rightmost address was added by the trusted proxy, the spoofed address in front of it is ignored
+	backendLogs.Reset()
+	checkRequest(t, "foo.example.com", envoyEndpoint+"/client-ip", http.MethodGet, http.StatusOK, false, "", "X-Forwarded-For", "203.0.113.7, 198.51.100.1")
+	checkInLogs(t, http.StatusOK, http.MethodGet, "/anything")
+}
+
+func TestE2EClientIPUntrustedPeer(t *testing.T) {
+	backendLogs.Reset()
+	checkRequest(t, "foo.example.com", envoyEndpoint+"/anything?client-ip", http.MethodGet, http.StatusOK, false, "", "X-Forwarded-For", "203.0.113.7")
+	checkInLogs(t, http.StatusOK, http.MethodGet, "/anything\\?client-ip")
+}
+
+// Testing connections without IP address, /internal is passed to an Envoy internal listener
+func TestE2EInternalListenerTrueNegative(t *testing.T) {
+	backendLogs.Reset()
+	checkRequest(t, "foo.example.com", envoyEndpoint+"/internal", http.MethodGet, http.StatusOK, false, "")
+	checkInLogs(t, http.StatusOK, http.MethodGet, "/anything")
+}
+
+func TestE2EInternalListenerClientAddressFromFilterState(t *testing.T) {
+	backendLogs.Reset()
+	checkRequest(t, "foo.example.com", envoyEndpoint+"/internal", http.MethodGet, http.StatusForbidden, true, "", "X-Test-Client-Address", "203.0.113.7")
+	checkNotInLogs(t, http.MethodGet, "/anything")
+}
+
+// Testing the route context variables
+func TestE2ERouteContextTrueNegative(t *testing.T) {
+	backendLogs.Reset()
+	checkRequest(t, "foo.example.com", envoyEndpoint+"/named-route/valid", http.MethodGet, http.StatusOK, false, "")
+	checkInLogs(t, http.StatusOK, http.MethodGet, "/anything/valid")
+}
+
+func TestE2ERouteContextRouteName(t *testing.T) {
+	backendLogs.Reset()
+	checkRequest(t, "foo.example.com", envoyEndpoint+"/named-route/routepayload", http.MethodGet, http.StatusForbidden, true, "")
+	checkNotInLogs(t, http.MethodGet, "/anything/routepayload")
+}
+
+func TestE2ERouteContextOtherRoute(t *testing.T) {
+	// the rule only applies to the named route
+	backendLogs.Reset()
+	checkRequest(t, "foo.example.com", envoyEndpoint+"/anything/routepayload", http.MethodGet, http.StatusOK, false, "")
+	checkInLogs(t, http.StatusOK, http.MethodGet, "/anything/routepayload")
+}
+
+func TestE2ERouteContextClusterAndFilterChain(t *testing.T) {
+	// the upstream cluster of the route is known before the request is sent upstream
+	backendLogs.Reset()
+	checkRequest(t, "foo.example.com", envoyEndpoint+"/anything/route-context", http.MethodGet, http.StatusForbidden, true, "")
+	checkNotInLogs(t, http.MethodGet, "/anything/route-context")
+}
+
+// Testing the decompression of compressed request bodies
+func gzipString(t *testing.T, data string) string {
+	var compressed bytes.Buffer
+	writer := gzip.NewWriter(&compressed)
+	_, err := writer.Write([]byte(data))
+	require.NoError(t, err)
+	require.NoError(t, writer.Close())
+	return compressed.String()
+}
+
+func TestE2ECompressedRequestBodyTrueNegative(t *testing.T) {
+	backendLogs.Reset()
+	checkRequest(t, "foo.example.com", envoyEndpoint+"/post", http.MethodPost, http.StatusOK, false, gzipString(t, "This is a valid payload"), "Content-Encoding", "gzip")
+	checkInLogs(t, http.StatusOK, http.MethodPost, "/post")
+}
+
+func TestE2ECompressedRequestBodyTruePositive(t *testing.T) {
+	backendLogs.Reset()
+	checkRequest(t, "foo.example.com", envoyEndpoint+"/post", http.MethodPost, http.StatusForbidden, true, gzipString(t, "maliciouspayload"), "Content-Encoding", "gzip")
+	checkNotInLogs(t, http.MethodPost, "/post")
+}
+
+func TestE2ECompressedRequestBodyTruePositiveTrailers(t *testing.T) {
+	// the body of a request ending with trailers is decompressed when the trailers arrive
+	backendLogs.Reset()
+	require.Equal(t, http.StatusForbidden, sendWithTrailer(t, envoyEndpoint+"/post", gzipString(t, "maliciouspayload"), "harmless", "Content-Encoding", "gzip"))
+	checkNotInLogs(t, http.MethodPost, "/post")
+}
+
+func TestE2ECompressedRequestBodyRatioExceeded(t *testing.T) {
+	backendLogs.Reset()
+	checkRequest(t, "foo.example.com", envoyEndpoint+"/post", http.MethodPost, http.StatusRequestEntityTooLarge, true, gzipString(t, strings.Repeat("a", 1<<20)), "Content-Encoding", "gzip")
+	checkNotInLogs(t, http.MethodPost, "/post")
+}
+
+func TestE2ECompressedRequestBodyUnsupportedEncodingInspectedRaw(t *testing.T) {
+	backendLogs.Reset()
+	checkRequest(t, "foo.example.com", envoyEndpoint+"/post", http.MethodPost, http.StatusForbidden, true, "maliciouspayload", "Content-Encoding", "compress")
+	checkNotInLogs(t, http.MethodPost, "/post")
+}
+
+// Testing the streaming of request bodies, the /streamed route inspects every 4096 bytes
+func TestE2EStreamedRequestBodyTrueNegative(t *testing.T) {
+	backendLogs.Reset()
+	data := strings.Repeat("a", 16*1024)
+	checkRequest(t, "foo.example.com", envoyEndpoint+"/streamed", http.MethodPost, http.StatusOK, false, data)
+	checkInLogs(t, http.StatusOK, http.MethodPost, "/anything")
+}
+
+func TestE2EStreamedRequestBodyTruePositiveWindow(t *testing.T) {
+	// the payload is found in the first window, the rest of the body is not passed upstream
+	data := "maliciouspayload" + strings.Repeat("a", 64*1024)
+	checkRequest(t, "foo.example.com", envoyEndpoint+"/streamed", http.MethodPost, http.StatusForbidden, true, data)
+}
+
+func TestE2EStreamedRequestBodyTruePositiveWindowBoundary(t *testing.T) {
+	// the payload is split by the first window boundary, the overlap finds it in the second window
+	data := strings.Repeat("a", 4096-8) + "maliciouspayload" + strings.Repeat("a", 64*1024)
+	checkRequest(t, "foo.example.com", envoyEndpoint+"/streamed", http.MethodPost, http.StatusForbidden, true, data)
+}
+
+func TestE2EStreamedRequestBodyTruePositiveCompleteBody(t *testing.T) {
+	// the body is smaller than a window, it is inspected at the end of the request
+	checkRequest(t, "foo.example.com", envoyEndpoint+"/streamed", http.MethodPost, http.StatusForbidden, true, "maliciouspayload")
+}
+
+func TestE2EStreamedRequestBodyRawWindowTrueNegative(t *testing.T) {
+	// a part of a JSON body is inspected raw, it does not fail the JSON body processor
+	backendLogs.Reset()
+	data := `{"data":"` + strings.Repeat("a", 16*1024) + `"}`
+	checkRequest(t, "foo.example.com", envoyEndpoint+"/streamed", http.MethodPost, http.StatusOK, false, data, "Content-Type", "application/json")
+	checkInLogs(t, http.StatusOK, http.MethodPost, "/anything")
+}
+
+func TestE2EStreamedRequestBodyRawWindowTruePositive(t *testing.T) {
+	data := `{"data":"maliciouspayload` + strings.Repeat("a", 64*1024) + `"}`
+	checkRequest(t, "foo.example.com", envoyEndpoint+"/streamed", http.MethodPost, http.StatusForbidden, true, data, "Content-Type", "application/json")
+}
+
+func TestE2EStreamedRequestBodyLimitReject(t *testing.T) {
+	// SecRequestBodyLimit 40 with SecRequestBodyLimitAction Reject applies to the streamed body in total
+	checkRequest(t, "baz.example.com", envoyEndpoint+"/streamed", http.MethodPost, http.StatusRequestEntityTooLarge, true, strings.Repeat("a", 1024))
+}
+
+func TestE2EStreamedRequestBodyLimitProcessPartial(t *testing.T) {
+	backendLogs.Reset()
+	// SecRequestBodyLimit 40 with SecRequestBodyLimitAction ProcessPartial, the data beyond the limit is not inspected
+	data := strings.Repeat("a", 40) + "maliciouspayload"
+	checkRequest(t, "bar.example.com", envoyEndpoint+"/streamed", http.MethodPost, http.StatusOK, false, data)
+	checkInLogs(t, http.StatusOK, http.MethodPost, "/anything")
+}
+
+func TestE2EStreamedRequestBodyTruePositiveTrailers(t *testing.T) {
+	// the rest of the body is inspected when the request ends with trailers
+	data := strings.Repeat("a", 4096+2048) + "maliciouspayload"
+	require.Equal(t, http.StatusForbidden, sendWithTrailer(t, envoyEndpoint+"/streamed", data, "harmless"))
+}
+
+// Testing the inspection of gRPC messages, decoded with the descriptor set in grpc/echo.pb
+const grpcEchoPath = "/test.e2e.v1.EchoService/Echo"
+
+// grpcEchoRequest returns a length-prefixed test.e2e.v1.EchoRequest message
+func grpcEchoRequest(t *testing.T, message string, compress bool) []byte {
+	// field 1, length delimited
+	payload := append([]byte{0x0a, byte(len(message))}, message...)
+	flag := byte(0)
+	if compress {
+		var compressed bytes.Buffer
+		writer := gzip.NewWriter(&compressed)
+		_, err := writer.Write(payload)
+		require.NoError(t, err)
+		require.NoError(t, writer.Close())
+		payload, flag = compressed.Bytes(), 1
+	}
+	frame := []byte{flag, 0, 0, 0, 0}
+	binary.BigEndian.PutUint32(frame[1:], uint32(len(payload)))
+	return append(frame, payload...)
+}
+
+func sendGrpc(t *testing.T, body []byte, headers ...string) *http.Response {
+	req, err := http.NewRequest(http.MethodPost, envoyEndpoint+grpcEchoPath, bytes.NewReader(body))
+	require.NoError(t, err)
+	req.Host = "grpc.example.com"
+	req.Header.Set("Content-Type", "application/grpc")
+	req.Header.Set("Te", "trailers")
+	for i := 0; i < len(headers); i += 2 {
+		req.Header.Set(headers[i], headers[i+1])
+	}
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	t.Cleanup(func() { resp.Body.Close() })
+	_, err = io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	return resp
+}
+
+func TestE2EGrpcTrueNegative(t *testing.T) {
+	backendLogs.Reset()
+	resp := sendGrpc(t, grpcEchoRequest(t, "hello", false))
+	require.Empty(t, resp.Header.Get("Grpc-Status"))
+	// httpbin does not implement the service, reaching it is enough
+	checkInLogs(t, http.StatusNotFound, http.MethodPost, grpcEchoPath)
+}
+
+func TestE2EGrpcTruePositiveDecodedMessage(t *testing.T) {
+	backendLogs.Reset()
+	resp := sendGrpc(t, grpcEchoRequest(t, "this is a grpcpayload", false))
+	require.Equal(t, "7", resp.Header.Get("Grpc-Status"))
+	checkNotInLogs(t, http.MethodPost, grpcEchoPath)
+}
+
+func TestE2EGrpcTruePositiveCompressedMessage(t *testing.T) {
+	backendLogs.Reset()
+	resp := sendGrpc(t, grpcEchoRequest(t, "this is a grpcpayload", true), "Grpc-Encoding", "gzip")
+	require.Equal(t, "7", resp.Header.Get("Grpc-Status"))
+	checkNotInLogs(t, http.MethodPost, grpcEchoPath)
+}
+
+func TestE2EGrpcTruePositiveLaterMessage(t *testing.T) {
+	backendLogs.Reset()
+	// every message is inspected on its own, the second one is blocked
+	body := append(grpcEchoRequest(t, "hello", false), grpcEchoRequest(t, "grpcpayload", false)...)
+	resp := sendGrpc(t, body)
+	require.Equal(t, "7", resp.Header.Get("Grpc-Status"))
+}
+
+func TestE2EGrpcTruncatedMessage(t *testing.T) {
+	backendLogs.Reset()
+	body := grpcEchoRequest(t, "hello", false)
+	resp := sendGrpc(t, body[:len(body)-1])
+	require.Equal(t, "3", resp.Header.Get("Grpc-Status"))
+}
+
 // Testing some CRS rules
 func TestE2ECRSXSSDetection(t *testing.T) {
 	backendLogs.Reset()
@@ -460,3 +913,285 @@ func TestE2EFilesystemRuleOtherWafNotBlock(t *testing.T) {
 	checkRequest(t, "custom.example.com", envoyEndpoint+"/admin", http.MethodGet, http.StatusNotFound, false, "")
 	checkInLogs(t, http.StatusNotFound, http.MethodGet, "/admin")
 }
+
+// Testing wildcard hosts in host_directive_map
+func TestE2EWildcardHostTruePositive(t *testing.T) {
+	backendLogs.Reset()
+	checkRequest(t, "tenant.wildcard.example.com", envoyEndpoint+"/evil", http.MethodGet, http.StatusForbidden, true, "")
+	checkNotInLogs(t, http.MethodGet, "/evil")
+}
+
+func TestE2EWildcardHostNestedSubdomainTruePositive(t *testing.T) {
+	backendLogs.Reset()
+	checkRequest(t, "a.tenant.wildcard.example.com", envoyEndpoint+"/evil", http.MethodGet, http.StatusForbidden, true, "")
+	checkNotInLogs(t, http.MethodGet, "/evil")
+}
+
+func TestE2EWildcardHostMostSpecificWins(t *testing.T) {
+	backendLogs.Reset()
+	checkRequest(t, "tenant.off.wildcard.example.com", envoyEndpoint+"/evil", http.MethodGet, http.StatusNotFound, false, "")
+	checkInLogs(t, http.StatusNotFound, http.MethodGet, "/evil")
+}
+
+func TestE2EWildcardHostDoesNotMatchSuffix(t *testing.T) {
+	backendLogs.Reset()
+	checkRequest(t, "wildcard.example.com", envoyEndpoint+"/evil", http.MethodGet, http.StatusNotFound, false, "")
+	checkInLogs(t, http.StatusNotFound, http.MethodGet, "/evil")
+}
+
+// Testing route_directive_map
+func TestE2ERouteDirectivePrefixAndMethod(t *testing.T) {
+	backendLogs.Reset()
+	checkRequest(t, "foo.example.com", envoyEndpoint+"/static/?arg=<script>alert(0)</script>", http.MethodGet, http.StatusNotFound, false, "")
+	checkInLogs(t, http.StatusNotFound, http.MethodGet, "/static/")
+}
+
+func TestE2ERouteDirectiveOtherMethodUsesHostDirective(t *testing.T) {
+	backendLogs.Reset()
+	checkRequest(t, "foo.example.com", envoyEndpoint+"/static/?arg=<script>alert(0)</script>", http.MethodPost, http.StatusForbidden, true, "")
+	checkNotInLogs(t, http.MethodPost, "/static/")
+}
+
+func TestE2ERouteDirectiveRegex(t *testing.T) {
+	data := fmt.Sprintf("this very long prefix is just a little more than 40 bytes %s suffix is 20 bytes", "maliciouspayload")
+	backendLogs.Reset()
+	checkRequest(t, "foo.example.com", envoyEndpoint+"/anything/upload", http.MethodPost, http.StatusOK, false, data)
+	checkInLogs(t, http.StatusOK, http.MethodPost, "/anything/upload")
+}
+
+func TestE2ERouteDirectiveRegexNoMatch(t *testing.T) {
+	data := fmt.Sprintf("this very long prefix is just a little more than 40 bytes %s suffix is 20 bytes", "maliciouspayload")
+	backendLogs.Reset()
+	checkRequest(t, "foo.example.com", envoyEndpoint+"/anything/uploads", http.MethodPost, http.StatusForbidden, true, data)
+	checkNotInLogs(t, http.MethodPost, "/anything/uploads")
+}
+
+func TestE2ERouteDirectiveDotSegments(t *testing.T) {
+	// the path leaves the /static/ prefix, the host directive applies
+	backendLogs.Reset()
+	checkRequest(t, "foo.example.com", envoyEndpoint+"/static/../anything?arg=<script>alert(0)</script>", http.MethodGet, http.StatusForbidden, true, "")
+	checkNotInLogs(t, http.MethodGet, "/anything")
+}
+
+func TestE2ERouteDirectiveEncodedDotSegments(t *testing.T) {
+	backendLogs.Reset()
+	checkRequest(t, "foo.example.com", envoyEndpoint+"/static/%2e%2e/anything?arg=<script>alert(0)</script>", http.MethodGet, http.StatusForbidden, true, "")
+	checkNotInLogs(t, http.MethodGet, "/anything")
+}
+
+// Testing directive set inheritance
+func TestE2EExtendsTrueNegative(t *testing.T) {
+	backendLogs.Reset()
+	checkRequest(t, "extends.example.com", envoyEndpoint+"/anything?arg=arg_1", http.MethodGet, http.StatusOK, false, "")
+	checkInLogs(t, http.StatusOK, http.MethodGet, "/anything\\?arg=arg_1")
+}
+
+func TestE2EExtendsTruePositiveOwnRule(t *testing.T) {
+	backendLogs.Reset()
+	checkRequest(t, "extends.example.com", envoyEndpoint+"/extended-admin", http.MethodGet, http.StatusForbidden, true, "")
+	checkNotInLogs(t, http.MethodGet, "/extended-admin")
+}
+
+func TestE2EExtendsTruePositiveParentRule(t *testing.T) {
+	backendLogs.Reset()
+	checkRequest(t, "extends.example.com", envoyEndpoint+"/admin", http.MethodGet, http.StatusForbidden, true, "")
+	checkNotInLogs(t, http.MethodGet, "/admin")
+}
+
+func TestE2EExtendsTruePositiveCRSFromBase(t *testing.T) {
+	backendLogs.Reset()
+	checkRequest(t, "extends.example.com", envoyEndpoint+"/anything?arg=<script>alert(0)</script>", http.MethodGet, http.StatusForbidden, true, "")
+	checkNotInLogs(t, http.MethodGet, "/anything")
+}
+
+// Testing per route configurations inheriting the listener directives
+func TestE2EInheritedRouteConfigDefaultDirective(t *testing.T) {
+	backendLogs.Reset()
+	checkRequest(t, "foo.example.com", envoyEndpoint+"/inherited-waf/admin", http.MethodGet, http.StatusNotFound, false, "")
+	checkInLogs(t, http.StatusNotFound, http.MethodGet, "/inherited-waf/admin")
+}
+
+func TestE2EInheritedRouteConfigHostDirectiveMap(t *testing.T) {
+	backendLogs.Reset()
+	checkRequest(t, "bar.example.com", envoyEndpoint+"/inherited-waf", http.MethodPost, http.StatusForbidden, true, "maliciouspayload")
+	checkNotInLogs(t, http.MethodPost, "/inherited-waf")
+}
+
+func TestE2EInheritedRouteConfigTruePositive(t *testing.T) {
+	backendLogs.Reset()
+	checkRequest(t, "foo.example.com", envoyEndpoint+"/inherited-waf", http.MethodPost, http.StatusNotFound, false, "maliciouspayload")
+	checkInLogs(t, http.StatusNotFound, http.MethodPost, "/inherited-waf")
+}
+
+// Testing configurable block responses
+func TestE2EBlockResponseTemplate(t *testing.T) {
+	backendLogs.Reset()
+	req, err := http.NewRequest(http.MethodGet, envoyEndpoint+"/anything/blocked", nil)
+	require.NoError(t, err)
+	req.Host = "block-page.example.com"
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+
+	require.Equal(t, http.StatusNotAcceptable, resp.StatusCode)
+	require.Equal(t, "text/plain", resp.Header.Get("Content-Type"))
+	require.Equal(t, "601", resp.Header.Get("X-Waf-Rule"))
+	require.Regexp(t, "^Request blocked by rule 601 in phase request_header, reference [0-9a-f-]+$", string(body))
+	checkNotInLogs(t, http.MethodGet, "/anything/blocked")
+}
+
+func TestE2EBlockResponseInheritedByExtends(t *testing.T) {
+	backendLogs.Reset()
+	_, body := checkRequest(t, "block-page-extended.example.com", envoyEndpoint+"/anything/extended-blocked", http.MethodGet, http.StatusNotAcceptable, false, "")
+	require.Contains(t, body, "Request blocked by rule 602 in phase request_header")
+	checkNotInLogs(t, http.MethodGet, "/anything/extended-blocked")
+}
+
+func TestE2EBlockResponseDefaultIsEmpty(t *testing.T) {
+	backendLogs.Reset()
+	checkRequest(t, "foo.example.com", envoyEndpoint+"/admin", http.MethodGet, http.StatusForbidden, true, "")
+	checkNotInLogs(t, http.MethodGet, "/admin")
+}
+
+func TestE2EBlockResponseHTMLForBrowsers(t *testing.T) {
+	backendLogs.Reset()
+	req, err := http.NewRequest(http.MethodGet, envoyEndpoint+"/admin", nil)
+	require.NoError(t, err)
+	req.Host = "foo.example.com"
+	req.Header.Set("Accept", "text/html,application/xhtml+xml,application/xml;q=0.9,*/*;q=0.8")
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+
+	require.Equal(t, http.StatusForbidden, resp.StatusCode)
+	require.Equal(t, "text/html; charset=utf-8", resp.Header.Get("Content-Type"))
+	require.Contains(t, string(body), "<title>403 Forbidden</title>")
+	require.Regexp(t, "Reference: [0-9a-f-]+", string(body))
+	checkNotInLogs(t, http.MethodGet, "/admin")
+}
+
+func TestE2EBlockResponseProblemJSONForAPIClients(t *testing.T) {
+	backendLogs.Reset()
+	req, err := http.NewRequest(http.MethodGet, envoyEndpoint+"/admin", nil)
+	require.NoError(t, err)
+	req.Host = "foo.example.com"
+	req.Header.Set("Accept", "application/json")
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+
+	require.Equal(t, http.StatusForbidden, resp.StatusCode)
+	require.Equal(t, "application/problem+json", resp.Header.Get("Content-Type"))
+	var problem map[string]any
+	require.NoError(t, json.Unmarshal(body, &problem))
+	require.Equal(t, "about:blank", problem["type"])
+	require.Equal(t, "Forbidden", problem["title"])
+	require.EqualValues(t, http.StatusForbidden, problem["status"])
+	require.NotEmpty(t, problem["request_id"])
+	checkNotInLogs(t, http.MethodGet, "/admin")
+}
+
+func TestE2EBlockResponseConfiguredWinsOverNegotiation(t *testing.T) {
+	backendLogs.Reset()
+	_, body := checkRequest(t, "block-page.example.com", envoyEndpoint+"/anything/negotiated-blocked", http.MethodGet, http.StatusNotAcceptable, false, "", "Accept", "application/json")
+	require.Contains(t, body, "Request blocked by rule 601 in phase request_header")
+	checkNotInLogs(t, http.MethodGet, "/anything/negotiated-blocked")
+}
+
+func TestE2EBlockResponseGrpc(t *testing.T) {
+	backendLogs.Reset()
+	req, err := http.NewRequest(http.MethodPost, envoyEndpoint+"/admin", strings.NewReader(""))
+	require.NoError(t, err)
+	req.Host = "foo.example.com"
+	req.Header.Set("Content-Type", "application/grpc")
+	req.Header.Set("Accept", "text/html")
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+
+	// a trailers-only reply has the grpc status in the headers and no body
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	require.Equal(t, "application/grpc", resp.Header.Get("Content-Type"))
+	require.Equal(t, "7", resp.Header.Get("Grpc-Status"))
+	require.Contains(t, resp.Header.Get("Grpc-Message"), "request blocked by the web application firewall")
+	require.Empty(t, body)
+	checkNotInLogs(t, http.MethodPost, "/admin")
+}
+
+func TestE2EBlockResponseGrpcWeb(t *testing.T) {
+	backendLogs.Reset()
+	// a gRPC-Web request is not a gRPC request, it gets the negotiated block response
+	_, body := checkRequest(t, "foo.example.com", envoyEndpoint+"/admin", http.MethodPost, http.StatusForbidden, false, "", "Content-Type", "application/grpc-web+proto", "Accept", "text/html")
+	require.Contains(t, body, "<h1>Forbidden</h1>")
+	checkNotInLogs(t, http.MethodPost, "/admin")
+}
+
+// Testing the reload of rules loaded from the filesystem
+func TestE2ERulesReload(t *testing.T) {
+	ctx := context.Background()
+	reloadRulesFile := "/etc/envoy/reload_rules/reload.conf"
+	initialRules, err := os.ReadFile(filepath.Join("reload_rules", "reload.conf"))
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		_ = envoyContainer.CopyToContainer(ctx, initialRules, reloadRulesFile, 0o444)
+	})
+
+	backendLogs.Reset()
+	checkRequest(t, "reload.example.com", envoyEndpoint+"/anything/reload-before", http.MethodGet, http.StatusForbidden, true, "")
+	checkRequest(t, "reload.example.com", envoyEndpoint+"/anything/reload-after", http.MethodGet, http.StatusOK, false, "")
+	checkInLogs(t, http.StatusOK, http.MethodGet, "/anything/reload-after")
+
+	// rules which do not compile are not loaded, the previous rules stay active
+	require.NoError(t, envoyContainer.CopyToContainer(ctx, []byte("SecRule REQUEST_URI \"@streq\"\n"), reloadRulesFile, 0o444))
+	time.Sleep(3 * time.Second)
+	checkRequest(t, "reload.example.com", envoyEndpoint+"/anything/reload-before", http.MethodGet, http.StatusForbidden, true, "")
+
+	newRules := []byte("SecRule REQUEST_URI \"@streq /anything/reload-after\" \"id:402,phase:1,t:lowercase,log,deny\"\n")
+	require.NoError(t, envoyContainer.CopyToContainer(ctx, newRules, reloadRulesFile, 0o444))
+	time.Sleep(3 * time.Second)
+	backendLogs.Reset()
+	checkRequest(t, "reload.example.com", envoyEndpoint+"/anything/reload-after", http.MethodGet, http.StatusForbidden, true, "")
+	checkNotInLogs(t, http.MethodGet, "/anything/reload-after")
+	checkRequest(t, "reload.example.com", envoyEndpoint+"/anything/reload-before", http.MethodGet, http.StatusOK, false, "")
+	checkInLogs(t, http.StatusOK, http.MethodGet, "/anything/reload-before")
+}
+
+// Testing the reload of data files without recompiling the WAF
+func TestE2EDataFileReload(t *testing.T) {
+	ctx := context.Background()
+	blocklistFile := "/etc/envoy/data_files/blocklist.txt"
+	phrasesFile := "/etc/envoy/data_files/phrases.txt"
+	initialBlocklist, err := os.ReadFile(filepath.Join("data_files", "blocklist.txt"))
+	require.NoError(t, err)
+	initialPhrases, err := os.ReadFile(filepath.Join("data_files", "phrases.txt"))
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		_ = envoyContainer.CopyToContainer(ctx, initialBlocklist, blocklistFile, 0o444)
+		_ = envoyContainer.CopyToContainer(ctx, initialPhrases, phrasesFile, 0o444)
+	})
+
+	backendLogs.Reset()
+	checkRequest(t, "data-files.example.com", envoyEndpoint+"/anything", http.MethodGet, http.StatusForbidden, true, "", "X-Client-Ip", "198.51.100.7")
+	checkRequest(t, "data-files.example.com", envoyEndpoint+"/anything?q=forbidden-phrase", http.MethodGet, http.StatusForbidden, true, "")
+	checkRequest(t, "data-files.example.com", envoyEndpoint+"/anything?q=new-phrase", http.MethodGet, http.StatusOK, false, "", "X-Client-Ip", "203.0.113.5")
+	checkInLogs(t, http.StatusOK, http.MethodGet, "/anything\\?q=new-phrase")
+
+	require.NoError(t, envoyContainer.CopyToContainer(ctx, []byte("203.0.113.0/24\n"), blocklistFile, 0o444))
+	require.NoError(t, envoyContainer.CopyToContainer(ctx, []byte("new-phrase\n"), phrasesFile, 0o444))
+	time.Sleep(3 * time.Second)
+
+	backendLogs.Reset()
+	checkRequest(t, "data-files.example.com", envoyEndpoint+"/anything", http.MethodGet, http.StatusForbidden, true, "", "X-Client-Ip", "203.0.113.5")
+	checkRequest(t, "data-files.example.com", envoyEndpoint+"/anything?q=new-phrase", http.MethodGet, http.StatusForbidden, true, "")
+	checkNotInLogs(t, http.MethodGet, "/anything\\?q=new-phrase")
+	checkRequest(t, "data-files.example.com", envoyEndpoint+"/anything?q=forbidden-phrase", http.MethodGet, http.StatusOK, false, "", "X-Client-Ip", "198.51.100.7")
+	checkInLogs(t, http.StatusOK, http.MethodGet, "/anything\\?q=forbidden-phrase")
+}
@@ -0,0 +1,62 @@
+//  Copyright © 2026 United Security Providers AG, Switzerland
+//  SPDX-License-Identifier: Apache-2.0
+
+package e2e
+
+import (
+	"crypto/tls"
+	"io"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// sendTLS sends a GET request to the TLS listener with the SNI and the Host header.
+func sendTLS(t *testing.T, sni string, host string, path string) int {
+	client := http.Client{
+		Transport: &http.Transport{
+			// the certificate of tests/e2e/tls is self-signed
+			TLSClientConfig: &tls.Config{ServerName: sni, InsecureSkipVerify: true, MinVersion: tls.VersionTLS13},
+		},
+	}
+	req, err := http.NewRequest(http.MethodGet, envoyTLSEndpoint+path, nil)
+	require.NoError(t, err)
+	req.Host = host
+
+	resp, err := client.Do(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	_, err = io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	return resp.StatusCode
+}
+
+func TestE2ETLSTrueNegative(t *testing.T) {
+	require.Equal(t, http.StatusOK, sendTLS(t, "tls.example.com", "tls.example.com", "/anything"))
+}
+
+func TestE2ETLSSNIVariable(t *testing.T) {
+	require.Equal(t, http.StatusForbidden, sendTLS(t, "tls.example.com", "tls.example.com", "/sni"))
+}
+
+func TestE2ETLSVersionVariable(t *testing.T) {
+	require.Equal(t, http.StatusForbidden, sendTLS(t, "tls.example.com", "tls.example.com", "/tls-version"))
+}
+
+func TestE2ETLSCipherVariable(t *testing.T) {
+	// the cipher is read from the SSL connection, the listener does not set a filter state object for it
+	require.Equal(t, http.StatusForbidden, sendTLS(t, "tls.example.com", "tls.example.com", "/cipher"))
+}
+
+func TestE2ETLSJA3Variable(t *testing.T) {
+	require.Equal(t, http.StatusForbidden, sendTLS(t, "tls.example.com", "tls.example.com", "/ja3"))
+}
+
+func TestE2ETLSDomainFronting(t *testing.T) {
+	require.Equal(t, http.StatusMisdirectedRequest, sendTLS(t, "tls.example.com", "other.example.com", "/anything"))
+}
+
+func TestE2ETLSHostWithPortMatchesSNI(t *testing.T) {
+	require.Equal(t, http.StatusOK, sendTLS(t, "tls.example.com", "tls.example.com:8443", "/anything"))
+}
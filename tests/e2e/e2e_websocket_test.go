@@ -0,0 +1,108 @@
+//  Copyright © 2026 United Security Providers AG, Switzerland
+//  SPDX-License-Identifier: Apache-2.0
+
+package e2e
+
+import (
+	"bufio"
+	"crypto/rand"
+	"encoding/binary"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	wsOpText  = 0x1
+	wsOpClose = 0x8
+)
+
+// dialWebsocket opens a WebSocket connection to the echo endpoint of httpbin
+func dialWebsocket(t *testing.T, host string) (net.Conn, *bufio.Reader) {
+	endpoint, err := url.Parse(envoyEndpoint)
+	require.NoError(t, err)
+	conn, err := net.Dial("tcp", endpoint.Host)
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+	require.NoError(t, conn.SetDeadline(time.Now().Add(5*time.Second)))
+
+	_, err = io.WriteString(conn, "GET /websocket/echo HTTP/1.1\r\n"+
+		"Host: "+host+"\r\n"+
+		"Upgrade: websocket\r\n"+
+		"Connection: Upgrade\r\n"+
+		"Sec-WebSocket-Key: dGhlIHNhbXBsZSBub25jZQ==\r\n"+
+		"Sec-WebSocket-Version: 13\r\n\r\n")
+	require.NoError(t, err)
+	reader := bufio.NewReader(conn)
+	resp, err := http.ReadResponse(reader, nil)
+	require.NoError(t, err)
+	require.Equal(t, http.StatusSwitchingProtocols, resp.StatusCode)
+	return conn, reader
+}
+
+// writeWebsocketFrame writes a single masked frame, like a client has to
+func writeWebsocketFrame(t *testing.T, conn net.Conn, opcode byte, payload string) {
+	frame := []byte{0x80 | opcode, 0x80 | byte(len(payload))}
+	mask := make([]byte, 4)
+	_, err := rand.Read(mask)
+	require.NoError(t, err)
+	frame = append(frame, mask...)
+	for i := 0; i < len(payload); i++ {
+		frame = append(frame, payload[i]^mask[i%4])
+	}
+	_, err = conn.Write(frame)
+	require.NoError(t, err)
+}
+
+// readWebsocketFrame reads a single unmasked frame with a payload of less than 126 bytes
+func readWebsocketFrame(t *testing.T, reader *bufio.Reader) (byte, []byte) {
+	header := make([]byte, 2)
+	_, err := io.ReadFull(reader, header)
+	require.NoError(t, err)
+	payload := make([]byte, header[1]&0x7f)
+	_, err = io.ReadFull(reader, payload)
+	require.NoError(t, err)
+	return header[0] & 0x0f, payload
+}
+
+func TestE2EWebsocketMessageTrueNegative(t *testing.T) {
+	conn, reader := dialWebsocket(t, "websocket.example.com")
+	writeWebsocketFrame(t, conn, wsOpText, "hello")
+	opcode, payload := readWebsocketFrame(t, reader)
+	require.Equal(t, byte(wsOpText), opcode)
+	require.Equal(t, "hello", string(payload))
+}
+
+func TestE2EWebsocketClientMessageTruePositive(t *testing.T) {
+	// the messages are inspected regardless of the request body access of the upgrade request
+	for _, host := range []string{"websocket.example.com", "websocket-body-access.example.com"} {
+		t.Run(host, func(t *testing.T) {
+			conn, reader := dialWebsocket(t, host)
+			writeWebsocketFrame(t, conn, wsOpText, "hello")
+			opcode, _ := readWebsocketFrame(t, reader)
+			require.Equal(t, byte(wsOpText), opcode)
+
+			// the message is replaced by a close frame to the server and the client gets a close frame with the policy violation code
+			writeWebsocketFrame(t, conn, wsOpText, "this is a wsattack")
+			opcode, payload := readWebsocketFrame(t, reader)
+			require.Equal(t, byte(wsOpClose), opcode)
+			require.GreaterOrEqual(t, len(payload), 2)
+			require.Equal(t, uint16(1008), binary.BigEndian.Uint16(payload))
+		})
+	}
+}
+
+func TestE2EWebsocketServerMessageTruePositive(t *testing.T) {
+	conn, reader := dialWebsocket(t, "websocket.example.com")
+	// the echo of the message is replaced by a close frame with the policy violation code
+	writeWebsocketFrame(t, conn, wsOpText, "this is a wsleak")
+	opcode, payload := readWebsocketFrame(t, reader)
+	require.Equal(t, byte(wsOpClose), opcode)
+	require.GreaterOrEqual(t, len(payload), 2)
+	require.Equal(t, uint16(1008), binary.BigEndian.Uint16(payload))
+}
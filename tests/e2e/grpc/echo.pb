@@ -0,0 +1,10 @@
+
+
+
+echo.prototest.e2e.v1"'
+EchoRequest
+message (	Rmessage"(
+EchoResponse
+message (	Rmessage2J
+EchoService;
+Echo.test.e2e.v1.EchoRequest.test.e2e.v1.EchoResponsebproto3
\ No newline at end of file